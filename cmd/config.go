@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/lucasdecamargo/go-appconfig-example/internal/config"
@@ -16,13 +20,34 @@ import (
 // FlagShowHidden controls whether hidden fields are displayed in output
 var FlagShowHidden bool
 
+// FlagShowSource controls whether the list command displays the source of each value
+var FlagShowSource bool
+
+// FlagScope selects the configuration scope of the set, unset, reset, edit, history and rollback commands
+var FlagScope string
+
+// FlagTarget designates the config file of the set, unset, reset, edit, history and rollback commands
+var FlagTarget string
+
+// FlagDefault controls whether the get command prints the default value
+var FlagDefault bool
+
 // configCmd represents the config command group
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configuration management commands",
 	Example: `confapp config list
 confapp config describe
-confapp config set --log.level debug`,
+confapp config get log.level
+confapp config explain log.level
+confapp config path
+confapp config edit
+confapp config watch
+confapp config set --log.level debug
+confapp config unset log.level
+confapp config reset proxy
+confapp config history
+confapp config rollback`,
 }
 
 // configListCmd lists configuration values
@@ -34,7 +59,9 @@ var configListCmd = &cobra.Command{
 	Example: `confapp config list
 confapp config list log
 confapp config list proxy
-confapp config list --hidden`,
+confapp config list --hidden
+confapp config list --source
+confapp config list -o json`,
 }
 
 // configDescribeCmd describes configuration parameters in detail
@@ -46,34 +73,228 @@ var configDescribeCmd = &cobra.Command{
 	Example: `confapp config describe
 confapp config describe log
 confapp config describe update
-confapp config describe --hidden`,
+confapp config describe --hidden
+confapp config describe -o yaml`,
+}
+
+// configGetCmd prints the raw value of a single configuration field
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print a configuration value",
+	Long:              `Print only the value of a configuration field, for use in scripts.`,
+	Args:              cobra.ExactArgs(1),
+	RunE:              getConfig,
+	ValidArgsFunction: generateFieldCompletions,
+	Example: `confapp config get log.level
+confapp config get update.period --default
+LEVEL=$(confapp config get log.level)`,
+}
+
+// configExplainCmd shows every layer that provides a value for a configuration field
+var configExplainCmd = &cobra.Command{
+	Use:   "explain <key>",
+	Short: "Explain how a configuration value is resolved",
+	Long: `Show every source that can provide a value for a configuration field, in order of
+precedence (highest first). The source providing the resolved value is marked with "*".`,
+	Args:              cobra.ExactArgs(1),
+	RunE:              explainConfig,
+	ValidArgsFunction: generateFieldCompletions,
+	Example: `confapp config explain log.level
+CONFAPP_LOG_LEVEL=debug confapp config explain log.level`,
+}
+
+// configValidateCmd validates configuration files
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file] ...",
+	Short: "Validate configuration files",
+	Long: `Validate every value set in the configuration files against the field definitions.
+If no file is given, the active config file is validated. All errors are reported at once,
+and the command exits with a non-zero status if any file is invalid.`,
+	RunE: validateConfig,
+	Example: `confapp config validate
+confapp config validate ./config.yaml
+confapp config validate deploy/*.yaml
+confapp config validate -o json`,
+}
+
+// configSchemaCmd prints the JSON Schema of the configuration file
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the configuration file",
+	Long: `Print a JSON Schema document describing the configuration file, for editors and CI.
+For example, yaml-language-server uses it for completion and validation when a YAML file
+starts with a "# yaml-language-server: $schema=<path>" comment.`,
+	Args: cobra.NoArgs,
+	RunE: printSchema,
+	Example: `confapp config schema
+confapp config schema > confapp.schema.json`,
+}
+
+// configPathCmd prints the configuration files that are considered
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the configuration file paths",
+	Long: `Print every configuration file that is considered, by scope, in the order they are
+looked up. Files that were found and loaded are marked with "*". The project file is
+looked up in the working directory and each of its parents, with every supported extension.
+The directory holding the state derived from the configuration (history, locks) is printed last.`,
+	Args: cobra.NoArgs,
+	RunE: printConfigPaths,
+	Example: `confapp config path
+confapp --config ./config.yaml config path`,
+}
+
+// configEditCmd opens a config file in the user's editor
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit a config file",
+	Long: `Open the config file of a scope (user by default) in $VISUAL or $EDITOR, creating it if
+needed. When the editor exits, the file is validated: while it is invalid, it is reopened
+with the errors annotated at the top. Exit the editor without changing the file to abort,
+which restores its original content.`,
+	Args: cobra.NoArgs,
+	RunE: editConfig,
+	Example: `confapp config edit
+confapp config edit --scope project
+EDITOR="code --wait" confapp config edit`,
+}
+
+// configWatchCmd watches the config file and reloads it on change
+var configWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the config file and reload it on change",
+	Long: `Watch the config file and print every value that changes when it is reloaded, until
+interrupted. A changed file is validated as a whole before it is applied: if it is invalid,
+the errors are printed and the previous configuration is kept.`,
+	Args: cobra.NoArgs,
+	RunE: watchConfig,
+	Example: `confapp config watch
+confapp config watch --config ./config.yaml`,
 }
 
 // configSetCmd sets configuration values
 var configSetCmd = &cobra.Command{
 	Use:   "set",
 	Short: "Set configuration values",
-	Long:  `For more information about the configuration values, use the "describe" command.`,
-	Args:  cobra.NoArgs,
-	RunE:  setConfig,
+	Long: `Set configuration values in the config file of a scope (user by default).
+For more information about the configuration values, use the "describe" command.`,
+	Args: cobra.NoArgs,
+	RunE: setConfig,
 	Example: `confapp config set --log.level info
 confapp config set --log.level debug --log.output /var/log/app.log
 confapp config set --update.auto true --update.period 1h
-confapp config set --proxy.http http://proxy:8080`,
+confapp config set --proxy.http http://proxy:8080
+confapp config set --scope project --log.level debug
+confapp -c base.yaml -c prod.yaml config set --target prod.yaml --log.level warn`,
 	ValidArgsFunction: generateSetCompletions,
 }
 
+// configUnsetCmd removes configuration values from the config file
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key> ...",
+	Short:             "Remove configuration values",
+	Long:              `Remove keys from the config file, so that they fall back to their default values.`,
+	Args:              cobra.MinimumNArgs(1),
+	RunE:              unsetConfig,
+	ValidArgsFunction: generateFieldCompletions,
+	Example: `confapp config unset log.level
+confapp config unset proxy.http proxy.https
+confapp config unset --scope project log.level`,
+}
+
+// configResetCmd restores configuration values to their defaults
+var configResetCmd = &cobra.Command{
+	Use:               "reset [prefix] ...",
+	Short:             "Reset configuration values to their defaults",
+	Long:              `Remove all keys matching the prefixes from the config file, or every key if no prefix is given.`,
+	RunE:              resetConfig,
+	ValidArgsFunction: generateFieldCompletions,
+	Example: `confapp config reset proxy
+confapp config reset log update
+confapp config reset`,
+}
+
+// configHistoryCmd lists the previous versions of a config file
+var configHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the previous versions of the config file",
+	Long: `List the previous versions of the config file of a scope (user by default), most recent
+first, with the time, the command and the keys of each change. The last 20 versions of each
+file are kept under the state directory.`,
+	Args: cobra.NoArgs,
+	RunE: listHistory,
+	Example: `confapp config history
+confapp config history --scope project`,
+}
+
+// configHistoryShowCmd shows a change of a config file
+var configHistoryShowCmd = &cobra.Command{
+	Use:   "show <n>",
+	Short: "Show the change made from a previous version of the config file",
+	Long: `Show the difference between the n-th previous version of the config file, as numbered
+by the "history" command, and the version that followed it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: showHistory,
+	Example: `confapp config history show 1
+confapp config history show 3 --scope project`,
+}
+
+// configRollbackCmd restores a previous version of a config file
+var configRollbackCmd = &cobra.Command{
+	Use:   "rollback [n]",
+	Short: "Restore a previous version of the config file",
+	Long: `Restore the n-th previous version of the config file, as numbered by the "history"
+command, or the version before the last change if n is not given. The rollback is itself
+recorded in the history, so that it can be undone with another rollback.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: rollbackConfig,
+	Example: `confapp config rollback
+confapp config rollback 3
+confapp config rollback --scope project`,
+}
+
 func init() {
 	// Add commands to the command tree
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configListCmd)
 	configCmd.AddCommand(configDescribeCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configExplainCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configWatchCmd)
 	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configResetCmd)
+	configCmd.AddCommand(configHistoryCmd)
+	configHistoryCmd.AddCommand(configHistoryShowCmd)
+	configCmd.AddCommand(configRollbackCmd)
 
 	// Add flags for showing hidden fields
 	configDescribeCmd.Flags().BoolVarP(&FlagShowHidden, "hidden", "", false, "Show hidden fields")
 	configListCmd.Flags().BoolVarP(&FlagShowHidden, "hidden", "", false, "Show hidden fields")
 
+	// Add flag for showing where each value came from
+	configListCmd.Flags().BoolVarP(&FlagShowSource, "source", "", false, "Show the source of each value")
+
+	// Add flags for the output format
+	addOutputFlag(configListCmd, outputFormats)
+	addOutputFlag(configDescribeCmd, outputFormats)
+	addOutputFlag(configValidateCmd, errorOutputFormats)
+
+	// Add flags for selecting the scope to write
+	for _, cmd := range []*cobra.Command{configSetCmd, configUnsetCmd, configResetCmd, configEditCmd, configHistoryCmd, configHistoryShowCmd, configRollbackCmd} {
+		cmd.Flags().StringVarP(&FlagScope, "scope", "", string(config.ScopeUser), fmt.Sprintf("Configuration scope to write %v", config.Scopes))
+		cmd.RegisterFlagCompletionFunc("scope", generateScopeCompletions)
+		cmd.Flags().StringVarP(&FlagTarget, "target", "", "", "Config file to write, instead of the file of the scope")
+		cmd.MarkFlagsMutuallyExclusive("scope", "target")
+	}
+
+	// Add flag for printing default values
+	configGetCmd.Flags().BoolVarP(&FlagDefault, "default", "", false, "Print the default value instead of the effective value")
+
 	// Set up flags for all configuration fields
 	setupConfigFlags()
 }
@@ -169,6 +390,15 @@ func generateFieldCompletions(cmd *cobra.Command, args []string, toComplete stri
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
+// generateScopeCompletions provides shell completion for the scope flag
+func generateScopeCompletions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	completions := make([]string, 0, len(config.Scopes))
+	for _, scope := range config.Scopes {
+		completions = append(completions, string(scope))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
 // generateSetCompletions provides shell completion for the set command
 func generateSetCompletions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) == 0 {
@@ -203,14 +433,27 @@ func listConfig(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no configuration fields found")
 	}
 
+	if err := validateOutputFormat(FlagOutput, outputFormats); err != nil {
+		return err
+	}
+	if FlagOutput != OutputText {
+		return writeValues(os.Stdout, FlagOutput, filterHiddenFields(selectedFields), FlagShowSource)
+	}
+
 	// Calculate maximum field name length for alignment
 	maxNameLen := calculateMaxFieldNameLength(selectedFields)
+	maxValueLen := calculateMaxValueLength(selectedFields)
 
 	// Display each field
 	for _, field := range selectedFields {
 		if field.Hidden && !FlagShowHidden {
 			continue
 		}
+		if FlagShowSource {
+			val := fmt.Sprint(config.ReadField(field))
+			fmt.Printf("%-*s = %-*s # %s\n", maxNameLen+1, field.Name, maxValueLen, val, config.SourceOf(field))
+			continue
+		}
 		fmt.Printf("%-*s = %v\n", maxNameLen+1, field.Name, config.ReadField(field))
 	}
 
@@ -225,6 +468,17 @@ func describeConfig(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no configuration fields found")
 	}
 
+	if err := validateOutputFormat(FlagOutput, outputFormats); err != nil {
+		return err
+	}
+	if FlagOutput != OutputText {
+		var sorted config.FieldCollection
+		for _, fields := range selectedFields.GroupIter() {
+			sorted = append(sorted, filterHiddenFields(fields)...)
+		}
+		return writeDescriptions(os.Stdout, FlagOutput, sorted)
+	}
+
 	w, cleanup := Pager()
 	defer cleanup()
 
@@ -244,6 +498,321 @@ func describeConfig(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// getConfig prints the value of a single configuration field
+func getConfig(cmd *cobra.Command, args []string) error {
+	field, ok := config.Fields.Map()[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown configuration field: %s", args[0])
+	}
+
+	var val any
+	if FlagDefault {
+		val = field.Default
+	} else {
+		var err error
+		if val, err = config.ReadTyped(field); err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+	}
+
+	fmt.Println(field.FormatValue(val))
+	return nil
+}
+
+// explainConfig displays the resolution chain of a single configuration field
+func explainConfig(cmd *cobra.Command, args []string) error {
+	field, ok := config.Fields.Map()[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown configuration field: %s", args[0])
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\n", field.Name)
+
+	resolved := false
+	for i, layer := range config.Explain(field) {
+		marker := " "
+		val := "(not set)"
+		if layer.Set {
+			val = field.FormatValue(layer.Value)
+			if !resolved {
+				marker = "*"
+				resolved = true
+			}
+		}
+		fmt.Fprintf(w, "  %s %d. %s\t%s\n", marker, i+1, layer.Source, val)
+	}
+
+	if !resolved {
+		fmt.Fprintf(w, "\nNo value is set\n")
+	}
+
+	return nil
+}
+
+// printConfigPaths prints the configuration files that are considered
+func printConfigPaths(cmd *cobra.Command, args []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, path := range config.ConfigPaths() {
+		marker := " "
+		status := "(not found)"
+		if path.Loaded {
+			marker = "*"
+			status = "(loaded)"
+		}
+		if path.IncludedBy != "" {
+			status = fmt.Sprintf("(loaded from %s)", path.IncludedBy)
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\n", marker, path.Scope, path.Path, status)
+	}
+
+	// Directory of the data derived from the configuration
+	fmt.Fprintf(w, "\n  state\t%s\n", config.StateDir())
+
+	return nil
+}
+
+// validateConfig validates configuration files and reports all errors
+func validateConfig(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(FlagOutput, errorOutputFormats); err != nil {
+		return err
+	}
+
+	files := args
+	if len(files) == 0 {
+		files = config.UserConfigFiles()
+	}
+
+	var errs []error
+	for _, file := range files {
+		if err := config.ValidateFile(file); err != nil {
+			errs = append(errs, flattenErrors(err)...)
+			continue
+		}
+		if FlagVerbose {
+			fmt.Printf("# %s is valid\n", file)
+		}
+	}
+
+	if len(errs) > 0 {
+		w := os.Stderr
+		if FlagOutput != OutputText {
+			w = os.Stdout
+		}
+		if err := writeErrors(w, FlagOutput, errs); err != nil {
+			return err
+		}
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+// printSchema prints the JSON Schema of the configuration file
+func printSchema(cmd *cobra.Command, args []string) error {
+	return encode(os.Stdout, OutputJSON, config.GenerateJSONSchema(config.Fields))
+}
+
+// editConfig opens the config file of the selected scope in the user's editor
+func editConfig(cmd *cobra.Command, args []string) error {
+	cfgFile, err := targetFile()
+	if err != nil {
+		return err
+	}
+
+	changed, err := editConfigFile(cfgFile)
+	if errors.Is(err, errEditAborted) {
+		fmt.Fprintf(os.Stderr, "Edit aborted: %s is still invalid and was restored\n", cfgFile)
+		os.Exit(1)
+	}
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Fprintln(os.Stderr, "Edit cancelled, no changes made")
+	} else if FlagVerbose {
+		fmt.Printf("# Saved config file: %s\n", cfgFile)
+	}
+	return nil
+}
+
+// listHistory lists the previous versions of a config file
+func listHistory(cmd *cobra.Command, args []string) error {
+	cfgFile, err := targetFile()
+	if err != nil {
+		return err
+	}
+	entries, err := config.History(cfgFile)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No history for %s\n", cfgFile)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "N\tTIME\tKEYS\tCOMMAND\n")
+	for i, entry := range entries {
+		keys := strings.Join(entry.Keys, ", ")
+		if keys == "" {
+			keys = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, formatHistoryTime(entry), keys, formatCommand(entry.Command))
+	}
+	return nil
+}
+
+// showHistory prints the change made from a previous version of a config file
+func showHistory(cmd *cobra.Command, args []string) error {
+	cfgFile, err := targetFile()
+	if err != nil {
+		return err
+	}
+	entries, err := config.History(cfgFile)
+	if err != nil {
+		return err
+	}
+	n, err := historyIndex(args[0], len(entries))
+	if err != nil {
+		return err
+	}
+	entry := entries[n-1]
+
+	// The version that followed is the one before the next change, or the
+	// current file for the last change
+	var after string
+	if n > 1 {
+		after = entries[n-2].Content
+	} else if data, err := os.ReadFile(cfgFile); err == nil {
+		after = string(data)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	fmt.Printf("# %s  %s\n", formatHistoryTime(entry), formatCommand(entry.Command))
+	fmt.Printf("--- %s (version %d)\n", entry.Path, n)
+	if n > 1 {
+		fmt.Printf("+++ %s (version %d)\n", entry.Path, n-1)
+	} else {
+		fmt.Printf("+++ %s (current)\n", entry.Path)
+	}
+	for _, line := range lineDiff(entry.Content, after) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// rollbackConfig restores a previous version of a config file
+func rollbackConfig(cmd *cobra.Command, args []string) error {
+	cfgFile, err := targetFile()
+	if err != nil {
+		return err
+	}
+
+	n := 1
+	if len(args) > 0 {
+		entries, err := config.History(cfgFile)
+		if err != nil {
+			return err
+		}
+		if n, err = historyIndex(args[0], len(entries)); err != nil {
+			return err
+		}
+	}
+
+	entry, err := config.Rollback(cfgFile, n)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s as it was before %s: %s\n", cfgFile, formatHistoryTime(*entry), formatCommand(entry.Command))
+	return nil
+}
+
+// historyIndex parses the number of a history entry
+func historyIndex(arg string, count int) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid version number: %s", arg)
+	}
+	if n > count {
+		return 0, fmt.Errorf("version %d not found, the history has %d versions", n, count)
+	}
+	return n, nil
+}
+
+// formatHistoryTime formats the time of a history entry in local time
+func formatHistoryTime(entry config.HistoryEntry) string {
+	return entry.Time.Local().Format(time.DateTime)
+}
+
+// formatCommand formats a command line, quoting the arguments that need it
+func formatCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// targetFile returns the config file designated with --target, or the file of
+// the scope selected with --scope
+func targetFile() (string, error) {
+	if FlagTarget != "" {
+		return FlagTarget, nil
+	}
+
+	scope, err := config.ParseScope(FlagScope)
+	if err != nil {
+		return "", err
+	}
+	cfgFile := config.ScopeFile(scope)
+	if cfgFile == "" {
+		return "", fmt.Errorf("no config file for scope %s, designate one with --target", scope)
+	}
+	return cfgFile, nil
+}
+
+// watchConfig reloads the config file on change until interrupted
+func watchConfig(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Watching %s (press Ctrl+C to stop)\n", strings.Join(config.UserConfigFiles(), ", "))
+	return config.Watch(ctx, func(format string, args ...any) {
+		fmt.Printf("%s %s\n", time.Now().Format(time.TimeOnly), fmt.Sprintf(format, args...))
+	})
+}
+
+// saveScope saves the configuration to the file designated with --target, or
+// to the file of the scope selected with --scope
+func saveScope() error {
+	if FlagTarget != "" {
+		if FlagVerbose {
+			fmt.Printf("# Writing config file: %s\n", FlagTarget)
+		}
+		return config.SaveFile(FlagTarget)
+	}
+
+	scope, err := config.ParseScope(FlagScope)
+	if err != nil {
+		return err
+	}
+	if FlagVerbose {
+		fmt.Printf("# Writing %s config file: %s\n", scope, config.ScopeFile(scope))
+	}
+	return config.SaveScope(scope)
+}
+
 // selectFieldsByPrefix filters fields based on the provided prefixes
 func selectFieldsByPrefix(prefixes []string) config.FieldCollection {
 	if len(prefixes) == 0 {
@@ -261,6 +830,21 @@ func selectFieldsByPrefix(prefixes []string) config.FieldCollection {
 	return selectedFields
 }
 
+// filterHiddenFields removes hidden fields unless they were requested with --hidden
+func filterHiddenFields(fields config.FieldCollection) config.FieldCollection {
+	if FlagShowHidden {
+		return fields
+	}
+
+	visible := config.FieldCollection{}
+	for _, field := range fields {
+		if !field.Hidden {
+			visible = append(visible, field)
+		}
+	}
+	return visible
+}
+
 // calculateMaxFieldNameLength determines the longest field name for alignment
 func calculateMaxFieldNameLength(fields config.FieldCollection) int {
 	maxLen := 0
@@ -272,6 +856,17 @@ func calculateMaxFieldNameLength(fields config.FieldCollection) int {
 	return maxLen
 }
 
+// calculateMaxValueLength determines the longest formatted value for alignment
+func calculateMaxValueLength(fields config.FieldCollection) int {
+	maxLen := 0
+	for _, field := range fields {
+		if l := len(fmt.Sprint(config.ReadField(field))); l > maxLen {
+			maxLen = l
+		}
+	}
+	return maxLen
+}
+
 // writeFieldDescription writes detailed information about a single field
 func writeFieldDescription(w interface{ Write([]byte) (int, error) }, field *config.Field) {
 	// Field name and deprecation status
@@ -291,6 +886,9 @@ func writeFieldDescription(w interface{ Write([]byte) (int, error) }, field *con
 		fmt.Fprintf(w, "    Value: %v\n", val)
 	}
 
+	// Source of the current value
+	fmt.Fprintf(w, "    Source: %s\n", config.SourceOf(field))
+
 	// Default value
 	if field.Default != nil {
 		fmt.Fprintf(w, "    Default: %v\n", field.Default)
@@ -338,8 +936,8 @@ func setConfig(cmd *cobra.Command, args []string) error {
 		return cmd.Help()
 	}
 
-	// Save the configuration to file
-	if err := config.Save(); err != nil {
+	// Save the configuration to the file of the selected scope
+	if err := saveScope(); err != nil {
 		fmt.Printf("Failed to save configuration: %v\n", err)
 		os.Exit(1)
 	}
@@ -359,7 +957,54 @@ func processFieldFlag(cmd *cobra.Command, field *config.Field) error {
 	}
 
 	if err := config.WriteField(field, flag.Value.String()); err != nil {
-		return fmt.Errorf("%s: %w", field.Name, err)
+		return err
+	}
+
+	return nil
+}
+
+// unsetConfig removes the given fields from the config file
+func unsetConfig(cmd *cobra.Command, args []string) error {
+	fieldMap := config.Fields.Map()
+	fields := make(config.FieldCollection, 0, len(args))
+	for _, key := range args {
+		field, ok := fieldMap[key]
+		if !ok {
+			return fmt.Errorf("unknown configuration field: %s", key)
+		}
+		fields = append(fields, field)
+	}
+
+	return unsetFields(fields)
+}
+
+// resetConfig restores the fields matching the given prefixes to their defaults
+func resetConfig(cmd *cobra.Command, args []string) error {
+	selectedFields := selectFieldsByPrefix(args)
+
+	if len(selectedFields) == 0 {
+		return fmt.Errorf("no configuration fields found")
+	}
+
+	return unsetFields(selectedFields)
+}
+
+// unsetFields removes fields from the config file and saves it
+func unsetFields(fields config.FieldCollection) error {
+	for _, field := range fields {
+		if FlagVerbose {
+			fmt.Printf("# Unsetting: %s\n", field.Name)
+		}
+
+		if err := config.UnsetField(field); err != nil {
+			return err
+		}
+	}
+
+	// Save the configuration to the file of the selected scope
+	if err := saveScope(); err != nil {
+		fmt.Printf("Failed to save configuration: %v\n", err)
+		os.Exit(1)
 	}
 
 	return nil
@@ -0,0 +1,58 @@
+package cmd
+
+import "strings"
+
+// lineDiff compares two texts line by line, based on the longest common
+// subsequence of their lines. Returns every line of both texts in order,
+// prefixed with " " if common to both, "-" if only in a and "+" if only in b.
+func lineDiff(a, b string) []string {
+	al, bl := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			out = append(out, " "+al[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+al[i])
+			i++
+		default:
+			out = append(out, "+"+bl[j])
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		out = append(out, "-"+al[i])
+	}
+	for ; j < len(bl); j++ {
+		out = append(out, "+"+bl[j])
+	}
+	return out
+}
+
+// splitLines splits a text into lines, without their line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
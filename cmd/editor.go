@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/lucasdecamargo/go-appconfig-example/internal/config"
+	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+)
+
+// errEditAborted is returned when the user leaves an invalid file unchanged
+var errEditAborted = errors.New("edit aborted")
+
+// annotationPrefix starts the lines annotating a config file with its errors.
+// Annotations are removed before the file is validated.
+var annotationPrefix = "# " + consts.AppName + ": "
+
+// editConfigFile opens a config file in the user's editor, and validates it
+// once the editor exits. While it is invalid, the file is reopened with the
+// errors annotated at the top. Leaving an invalid file unchanged aborts the
+// edit and restores the original content. A valid change is recorded in the
+// history of the file. The file stays locked during the edit, so that
+// concurrent saves wait for it instead of being overwritten. Returns whether
+// the file changed.
+func editConfigFile(cfgFile string) (bool, error) {
+	unlock, err := config.LockFile(cfgFile)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if err := config.CreateConfigFile(cfgFile); err != nil {
+		return false, err
+	}
+
+	original, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return false, err
+	}
+
+	content := original
+	var annotation []byte
+	for {
+		if err := config.WriteFile(cfgFile, append(annotation, content...)); err != nil {
+			return false, err
+		}
+		if err := runEditor(cfgFile); err != nil {
+			return false, errors.Join(err, restoreFile(cfgFile, original))
+		}
+
+		edited, err := os.ReadFile(cfgFile)
+		if err != nil {
+			return false, err
+		}
+		edited = stripAnnotation(edited)
+
+		if bytes.Equal(edited, content) {
+			if annotation != nil {
+				return false, errors.Join(errEditAborted, restoreFile(cfgFile, original))
+			}
+			return false, nil
+		}
+		content = edited
+
+		if err := config.WriteFile(cfgFile, content); err != nil {
+			return false, err
+		}
+		verr := config.ValidateFile(cfgFile)
+		if verr == nil {
+			if err := config.RecordHistory(cfgFile, original); err != nil {
+				printWarning(fmt.Errorf("failed to record config history: %w", err))
+			}
+			return true, nil
+		}
+		annotation = annotateErrors(cfgFile, flattenErrors(verr))
+	}
+}
+
+// runEditor opens a file in the editor set in $VISUAL or $EDITOR, and waits
+// for it to exit. Defaults to vi (notepad on Windows).
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may be set with arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], file)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
+
+// annotateErrors formats errors as comments to prepend to a config file.
+// Error positions are shifted to account for the annotation lines.
+func annotateErrors(cfgFile string, errs []error) []byte {
+	lines := []string{
+		fmt.Sprintf("%s is invalid. Fix the errors below, or exit", cfgFile),
+		"without changing the file to abort and restore it.",
+		"",
+	}
+	for _, err := range errs {
+		lines = append(lines, strings.Split(err.Error(), "\n")...)
+	}
+
+	// Annotations take one line per message line, plus a blank line
+	offset := len(lines) + 1
+	lines = lines[:3]
+	for _, err := range errs {
+		shiftErrorLine(err, cfgFile, offset)
+		lines = append(lines, strings.Split(err.Error(), "\n")...)
+	}
+
+	var b bytes.Buffer
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(annotationPrefix+line, " ") + "\n")
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// shiftErrorLine adds offset to the line reported by an error of a config
+// file. Errors of other files, such as included ones, are left unchanged.
+func shiftErrorLine(err error, cfgFile string, offset int) {
+	var (
+		verr *config.ValidationError
+		uerr *config.UnknownKeyError
+		ferr *config.FileError
+	)
+	switch {
+	case errors.As(err, &verr) && verr.Line > 0 && verr.Source.Name == cfgFile:
+		verr.Line += offset
+	case errors.As(err, &uerr) && uerr.Line > 0 && uerr.Source.Name == cfgFile:
+		uerr.Line += offset
+	case errors.As(err, &ferr) && ferr.Line > 0 && ferr.Path == cfgFile:
+		ferr.Line += offset
+	}
+}
+
+// stripAnnotation removes the error annotation from the top of a config file
+func stripAnnotation(data []byte) []byte {
+	trimmed := strings.TrimRight(annotationPrefix, " ")
+	stripped := false
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		if !bytes.HasPrefix(line, []byte(trimmed)) {
+			break
+		}
+		data = rest
+		stripped = true
+	}
+	// Remove the blank line separating the annotation from the content
+	if stripped {
+		data = bytes.TrimPrefix(data, []byte("\n"))
+	}
+	return data
+}
+
+// restoreFile writes back the original content of an edited file
+func restoreFile(file string, original []byte) error {
+	return config.WriteFile(file, original)
+}
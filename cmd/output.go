@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lucasdecamargo/go-appconfig-example/internal/config"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+	OutputTOML = "toml"
+	OutputEnv  = "env"
+)
+
+// outputFormats lists the formats supported by the list and describe commands
+var outputFormats = []string{OutputText, OutputJSON, OutputYAML, OutputTOML, OutputEnv}
+
+// errorOutputFormats lists the formats supported for error reports
+var errorOutputFormats = []string{OutputText, OutputJSON, OutputYAML}
+
+// FlagOutput selects the output format of the list, describe and validate commands
+var FlagOutput string
+
+// addOutputFlag adds the --output flag to a command, accepting the given formats
+func addOutputFlag(cmd *cobra.Command, formats []string) {
+	cmd.Flags().StringVarP(&FlagOutput, "output", "o", OutputText,
+		fmt.Sprintf("Output format (%s)", strings.Join(formats, ", ")))
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return formats, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// validateOutputFormat returns an error if the format is not one of formats
+func validateOutputFormat(format string, formats []string) error {
+	if !slices.Contains(formats, format) {
+		return fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(formats, ", "))
+	}
+	return nil
+}
+
+// fieldDescription is the structured representation of a configuration field
+type fieldDescription struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`
+	Group       string `json:"group,omitempty" yaml:"group,omitempty" toml:"group,omitempty"`
+	Type        string `json:"type" yaml:"type" toml:"type"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Docstring   string `json:"docstring,omitempty" yaml:"docstring,omitempty" toml:"docstring,omitempty"`
+	Default     any    `json:"default,omitempty" yaml:"default,omitempty" toml:"default,omitempty"`
+	Value       any    `json:"value,omitempty" yaml:"value,omitempty" toml:"value,omitempty"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty" toml:"error,omitempty"`
+	Source      string `json:"source" yaml:"source" toml:"source"`
+	Scope       string `json:"scope,omitempty" yaml:"scope,omitempty" toml:"scope,omitempty"`
+	ValidValues []any  `json:"valid_values,omitempty" yaml:"valid_values,omitempty" toml:"valid_values,omitempty"`
+	ValidateTag string `json:"validate_tag,omitempty" yaml:"validate_tag,omitempty" toml:"validate_tag,omitempty"`
+	Example     string `json:"example,omitempty" yaml:"example,omitempty" toml:"example,omitempty"`
+	Deprecated  string `json:"deprecated,omitempty" yaml:"deprecated,omitempty" toml:"deprecated,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty" yaml:"hidden,omitempty" toml:"hidden,omitempty"`
+	Shorthand   string `json:"shorthand,omitempty" yaml:"shorthand,omitempty" toml:"shorthand,omitempty"`
+	Env         string `json:"env" yaml:"env" toml:"env"`
+}
+
+// fieldDescriptions is the document emitted by describe in structured formats
+type fieldDescriptions struct {
+	Fields []fieldDescription `json:"fields" yaml:"fields" toml:"fields"`
+}
+
+// describeField builds the structured description of a field
+func describeField(field *config.Field) fieldDescription {
+	val, verr := readValue(field)
+	source := config.SourceOf(field)
+	return fieldDescription{
+		Name:        field.Name,
+		Group:       field.Group,
+		Type:        string(field.Type),
+		Description: field.Description,
+		Docstring:   field.Docstring,
+		Default:     outputValue(field, field.Default),
+		Value:       val,
+		Error:       verr,
+		Source:      source.String(),
+		Scope:       string(source.Scope),
+		ValidValues: field.ValidValues,
+		ValidateTag: field.ValidateTag,
+		Example:     field.Example,
+		Deprecated:  field.Deprecated,
+		Hidden:      field.Hidden,
+		Shorthand:   field.Shorthand,
+		Env:         config.EnvVarName(field),
+	}
+}
+
+// readValue reads the value of a field for structured output.
+// A value that cannot be converted to the field type is returned as it was
+// read, along with the conversion error, rather than as the zero value.
+func readValue(field *config.Field) (any, string) {
+	val, err := config.ReadTyped(field)
+	if err != nil {
+		return config.ReadField(field), err.Error()
+	}
+	return outputValue(field, val), ""
+}
+
+// outputValue converts a field value for structured output.
+// Durations are formatted as strings since encoders would print nanoseconds.
+func outputValue(field *config.Field, val any) any {
+	if val != nil && field.Type == config.FieldTypeDuration {
+		return field.FormatValue(val)
+	}
+	return val
+}
+
+// writeValues writes field values in a structured format.
+// For json, yaml and toml the values are nested by key, like in a config file.
+// With withSource, each value is paired with its source.
+func writeValues(w io.Writer, format string, fields config.FieldCollection, withSource bool) error {
+	if format == OutputEnv {
+		for _, field := range fields {
+			val, verr := readValue(field)
+			if withSource {
+				fmt.Fprintf(w, "# %s\n", config.SourceOf(field))
+			}
+			if verr != "" {
+				fmt.Fprintf(w, "# error: %s\n", verr)
+			}
+			fmt.Fprintf(w, "%s=%s\n", config.EnvVarName(field), quoteEnvValue(field.FormatValue(val)))
+		}
+		return nil
+	}
+
+	values := make(map[string]any)
+	for _, field := range fields {
+		val, verr := readValue(field)
+		if val == nil && format == OutputTOML {
+			continue // TOML has no null value
+		}
+		if withSource {
+			source := config.SourceOf(field)
+			entry := map[string]any{
+				"value":  val,
+				"source": source.String(),
+			}
+			if source.Scope != "" {
+				entry["scope"] = string(source.Scope)
+			}
+			if verr != "" {
+				entry["error"] = verr
+			}
+			setNestedValue(values, field.Name, entry)
+			continue
+		}
+		setNestedValue(values, field.Name, val)
+	}
+	return encode(w, format, values)
+}
+
+// writeDescriptions writes field descriptions in a structured format
+func writeDescriptions(w io.Writer, format string, fields config.FieldCollection) error {
+	if format == OutputEnv {
+		for _, field := range fields {
+			fmt.Fprintf(w, "# %s: %s\n", field.Name, field.Description)
+			fmt.Fprintf(w, "# Type: %s\n", field.Type)
+			if field.ValidValues != nil {
+				fmt.Fprintf(w, "# Valid values: %v\n", field.ValidValues)
+			}
+			if field.Example != "" {
+				fmt.Fprintf(w, "# Example: %s\n", field.Example)
+			}
+			if field.Deprecated != "" {
+				fmt.Fprintf(w, "# Deprecated: %s\n", field.Deprecated)
+			}
+			fmt.Fprintf(w, "%s=%s\n\n", config.EnvVarName(field), quoteEnvValue(field.FormatValue(field.Default)))
+		}
+		return nil
+	}
+
+	doc := fieldDescriptions{Fields: make([]fieldDescription, 0, len(fields))}
+	for _, field := range fields {
+		doc.Fields = append(doc.Fields, describeField(field))
+	}
+	return encode(w, format, doc)
+}
+
+// encode writes v in the json, yaml or toml format
+func encode(w io.Writer, format string, v any) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(v)
+	case OutputTOML:
+		return toml.NewEncoder(w).Encode(v)
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// setNestedValue sets a dotted key in nested maps
+func setNestedValue(m map[string]any, key string, val any) {
+	parent, leaf, found := strings.Cut(key, ".")
+	if !found {
+		m[key] = val
+		return
+	}
+
+	child, ok := m[parent].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		m[parent] = child
+	}
+	setNestedValue(child, leaf, val)
+}
+
+// quoteEnvValue quotes a value for an env file if it contains special characters
+func quoteEnvValue(val string) string {
+	safe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.,/:@+%", r)
+	}
+	for _, r := range val {
+		if !safe(r) {
+			return "'" + strings.ReplaceAll(val, "'", `'\''`) + "'"
+		}
+	}
+	return val
+}
+
+// errorReport is the document emitted for errors in structured formats
+type errorReport struct {
+	Errors []any `json:"errors" yaml:"errors"`
+}
+
+// errorMessage is the structured representation of an error that is not a
+// validation error, such as a file that cannot be parsed
+type errorMessage struct {
+	Message string `json:"message" yaml:"message"`
+}
+
+// flattenErrors returns the errors joined in err, recursively
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, flattenErrors(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
+
+// writeErrors writes errors as text (one per line) or as a structured report.
+// Validation errors are reported with all their attributes.
+func writeErrors(w io.Writer, format string, errs []error) error {
+	if format == OutputText {
+		for _, err := range errs {
+			fmt.Fprintln(w, err)
+		}
+		return nil
+	}
+
+	report := errorReport{Errors: make([]any, 0, len(errs))}
+	for _, err := range errs {
+		var (
+			verr *config.ValidationError
+			uerr *config.UnknownKeyError
+		)
+		switch {
+		case errors.As(err, &verr):
+			report.Errors = append(report.Errors, validationReport(verr))
+		case errors.As(err, &uerr):
+			report.Errors = append(report.Errors, unknownKeyReport(uerr))
+		default:
+			report.Errors = append(report.Errors, errorMessage{Message: err.Error()})
+		}
+	}
+	return encode(w, format, report)
+}
+
+// validationEntry is the structured representation of a validation error
+type validationEntry struct {
+	Field   string `json:"field" yaml:"field"`
+	Value   any    `json:"value" yaml:"value"`
+	Source  string `json:"source,omitempty" yaml:"source,omitempty"`
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column  int    `json:"column,omitempty" yaml:"column,omitempty"`
+	Rule    string `json:"rule" yaml:"rule"`
+	Detail  string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// validationReport converts a validation error for structured output
+func validationReport(verr *config.ValidationError) validationEntry {
+	entry := validationEntry{
+		Field:   verr.Field,
+		Value:   verr.Value,
+		Line:    verr.Line,
+		Column:  verr.Column,
+		Rule:    verr.Rule,
+		Detail:  verr.Detail,
+		Message: verr.Message,
+	}
+	if verr.Source.Kind != "" {
+		entry.Source = verr.Source.String()
+	}
+	return entry
+}
+
+// unknownKeyEntry is the structured representation of an unknown key error
+type unknownKeyEntry struct {
+	Key        string `json:"key" yaml:"key"`
+	Source     string `json:"source" yaml:"source"`
+	Line       int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column     int    `json:"column,omitempty" yaml:"column,omitempty"`
+	Suggestion string `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+	Message    string `json:"message" yaml:"message"`
+}
+
+// unknownKeyReport converts an unknown key error for structured output
+func unknownKeyReport(uerr *config.UnknownKeyError) unknownKeyEntry {
+	return unknownKeyEntry{
+		Key:        uerr.Key,
+		Source:     uerr.Source.String(),
+		Line:       uerr.Line,
+		Column:     uerr.Column,
+		Suggestion: uerr.Suggestion,
+		Message:    uerr.Error(),
+	}
+}
@@ -3,18 +3,19 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/lucasdecamargo/go-appconfig-example/internal/config"
 	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // Global flag variables that are bound to the root command
 var (
-	FlagConfig  string // Path to the configuration file
-	FlagVerbose bool   // Enable verbose output
+	FlagConfig  []string // Paths to the user configuration files
+	FlagVerbose bool     // Enable verbose output
+	FlagStrict  bool     // Validate all configuration values at startup
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -44,22 +45,18 @@ func init() {
 }
 
 // setupPersistentFlags configures the global flags that are available to all commands.
-// These flags are bound to Viper for automatic configuration integration.
+// These flags are bound to their configuration fields for automatic configuration integration.
 func setupPersistentFlags() {
-	// Determine default config file location
-	configDir, _ := os.UserConfigDir()
-	defaultConfig := path.Join(configDir, consts.AppName, "config.yaml")
-
-	// Config file flag
-	rootCmd.PersistentFlags().StringVarP(
-		&FlagConfig,
+	// Config file flag, repeatable
+	FlagConfig = []string{config.DefaultUserConfigFile()}
+	rootCmd.PersistentFlags().VarP(
+		&pathListValue{paths: &FlagConfig},
 		config.FieldFlagConfig.Name,
 		config.FieldFlagConfig.Shorthand,
-		defaultConfig,
 		config.FieldFlagConfig.Description,
 	)
-	viper.BindPFlag(
-		config.FieldFlagConfig.Name,
+	config.BindFlag(
+		config.FieldFlagConfig.Field,
 		rootCmd.PersistentFlags().Lookup(config.FieldFlagConfig.Name),
 	)
 
@@ -71,16 +68,54 @@ func setupPersistentFlags() {
 		false,
 		config.FieldFlagVerbose.Description,
 	)
-	viper.BindPFlag(
-		config.FieldFlagVerbose.Name,
+	config.BindFlag(
+		config.FieldFlagVerbose.Field,
 		rootCmd.PersistentFlags().Lookup(config.FieldFlagVerbose.Name),
 	)
+
+	// Strict flag
+	rootCmd.PersistentFlags().BoolVarP(
+		&FlagStrict,
+		config.FieldFlagStrict.Name,
+		config.FieldFlagStrict.Shorthand,
+		false,
+		config.FieldFlagStrict.Description,
+	)
+	config.BindFlag(
+		config.FieldFlagStrict.Field,
+		rootCmd.PersistentFlags().Lookup(config.FieldFlagStrict.Name),
+	)
+}
+
+// pathListValue is a repeatable flag value holding a list of paths.
+// It is formatted as a path list (e.g. "base.yaml:prod.yaml" on Unix), which is
+// the value bound to the config field. Values replace the default on first use.
+type pathListValue struct {
+	paths   *[]string
+	changed bool
+}
+
+func (v *pathListValue) Set(s string) error {
+	if !v.changed {
+		*v.paths = nil
+		v.changed = true
+	}
+	*v.paths = append(*v.paths, filepath.SplitList(s)...)
+	return nil
+}
+
+func (v *pathListValue) String() string {
+	return strings.Join(*v.paths, string(os.PathListSeparator))
+}
+
+func (v *pathListValue) Type() string {
+	return "paths"
 }
 
 // initConfig reads in config file and ENV variables if set.
 // This function is called by Cobra before any command execution.
 func initConfig() {
-	if err := config.Init(); err != nil {
+	if err := config.Init(config.WithWarningHandler(printWarning)); err != nil {
 		fmt.Printf("Configuration error: %v\n", err)
 		os.Exit(1)
 	}
@@ -91,13 +126,17 @@ func initConfig() {
 	}
 }
 
+// printWarning displays a configuration warning on the standard error
+func printWarning(err error) {
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+}
+
 // printConfigInfo displays information about the current configuration
 // when verbose mode is enabled.
 func printConfigInfo() {
-	cfgFile := config.ReadFieldString(config.FieldFlagConfig)
-	if cfgFile != "" {
-		fmt.Printf("# Using config file: %s\n", cfgFile)
-	} else {
-		fmt.Printf("# No config file found, using default values\n")
+	for _, path := range config.ConfigPaths() {
+		if path.Loaded {
+			fmt.Printf("# Using %s config file: %s\n", path.Scope, path.Path)
+		}
 	}
 }
@@ -0,0 +1,44 @@
+package config
+
+import "time"
+
+// AppConfig is the typed view of the application configuration.
+// Each struct field is bound to the Field named by its `config` tag; nested
+// structs add their tag as a prefix (e.g. Log.Level is bound to "log.level").
+type AppConfig struct {
+	Environment string       `config:"environment"`
+	Log         LogConfig    `config:"log"`
+	Update      UpdateConfig `config:"update"`
+	Proxy       ProxyConfig  `config:"proxy"`
+}
+
+// LogConfig holds the logging configuration
+type LogConfig struct {
+	Level  string `config:"level"`
+	Output string `config:"output"`
+	Format string `config:"format"`
+}
+
+// UpdateConfig holds the application update configuration
+type UpdateConfig struct {
+	Unstable bool          `config:"unstable"`
+	Auto     bool          `config:"auto"`
+	Period   time.Duration `config:"period"`
+}
+
+// ProxyConfig holds the network proxy configuration
+type ProxyConfig struct {
+	All   string `config:"all"`
+	HTTP  string `config:"http"`
+	HTTPS string `config:"https"`
+}
+
+// Load returns the resolved configuration as an AppConfig.
+// Init must be called first. See Unmarshal for the errors returned.
+func Load() (*AppConfig, error) {
+	var cfg AppConfig
+	if err := Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// saveMu serializes the saves of this process. File locks (see lockFile) may
+// not exclude each other within a process, depending on the platform.
+var saveMu sync.Mutex
+
+// lockConfigFile takes an exclusive advisory lock for a read-modify-write of a
+// config file, blocking until other processes release it. The lock is held on
+// a lock file under the state directory (see StateDir), named after a hash of
+// the path of the config file, since the config file itself is replaced on
+// each write and lock files should not clutter config directories, such as
+// project repositories. Symbolic links are followed, so that all paths to a
+// file share its lock. Returns the function releasing the lock.
+func lockConfigFile(cfgFile string) (func(), error) {
+	lockPath := cfgFile + ".lock"
+	if dir := StateDir(); dir != "" {
+		dir = filepath.Join(dir, "locks")
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create lock directory: %w", err)
+		}
+		lockPath = filepath.Join(dir, pathKey(cfgFile)+".lock")
+	}
+
+	saveMu.Lock()
+
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		saveMu.Unlock()
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		saveMu.Unlock()
+		return nil, fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+		saveMu.Unlock()
+	}, nil
+}
+
+// LockFile takes the lock that serializes the changes to a config file, for
+// changes made outside of Save (e.g. in an editor), so that concurrent saves
+// wait for them instead of being lost. Save must not be called while holding
+// the lock. Returns the function releasing the lock.
+func LockFile(cfgFile string) (func(), error) {
+	return lockConfigFile(cfgFile)
+}
+
+// WriteFile atomically replaces the content of a config file, preserving its
+// mode and ownership, like Save does. The file should be locked with LockFile.
+func WriteFile(cfgFile string, data []byte) error {
+	return writeFileAtomic(cfgFile, data, 0644)
+}
+
+// canonicalPath returns the absolute path identifying a config file, in its
+// history and for its lock. Symbolic links are followed, so that all paths to
+// a file are identified alike, whether or not the file exists yet.
+func canonicalPath(cfgFile string) string {
+	path, err := filepath.Abs(cfgFile)
+	if err != nil {
+		path = cfgFile
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
+
+// pathKey returns a file name identifying a config file, derived from a hash
+// of its canonical path
+func pathKey(cfgFile string) string {
+	sum := sha256.Sum256([]byte(canonicalPath(cfgFile)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// writeFileAtomic replaces the content of a file by writing a temporary file
+// in the same directory and renaming it over the file, so that readers and
+// crashes never observe a partially written file. The mode and ownership of
+// an existing file are preserved; new files are created with perm. A file
+// owned by another user is written in place instead, with a warning, since it
+// cannot be replaced without changing its owner. Symbolic links are followed,
+// so that the target file is replaced.
+func writeFileAtomic(file string, data []byte, perm fs.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(file); err == nil {
+		file = resolved
+	}
+
+	info, err := os.Stat(file)
+	switch {
+	case err == nil:
+		perm = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if info != nil {
+		if err := chownLike(tmp.Name(), info); err != nil {
+			// The file cannot be replaced without changing its owner (e.g. a
+			// group-writable file owned by another user): write it in place,
+			// which a crash may leave partially written.
+			warn(fmt.Errorf("cannot preserve the owner of %s by replacing it, writing it in place instead: %w", file, err))
+			return os.WriteFile(file, data, perm)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(file))
+	return nil
+}
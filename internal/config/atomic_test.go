@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// saveValues are the values set by the processes of TestConcurrentSaves,
+// one field each
+var saveValues = map[string]any{
+	"environment":     "prod",
+	"log.level":       "warn",
+	"log.output":      "/var/log/app.log",
+	"log.format":      "json",
+	"update.unstable": true,
+	"update.auto":     true,
+	"update.period":   "1h",
+	"proxy.all":       "http://proxy:8080",
+	"proxy.http":      "http://proxy:8081",
+	"proxy.https":     "http://proxy:8082",
+}
+
+// TestConcurrentSaves saves a different field to the same file from parallel
+// processes, and checks that no change is lost.
+func TestConcurrentSaves(t *testing.T) {
+	if name := os.Getenv("CONFIG_TEST_SAVE_FIELD"); name != "" {
+		saveTestField(t, name, os.Getenv("CONFIG_TEST_SAVE_FILE"))
+		return
+	}
+
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	for _, ext := range []string{"yaml", "toml", "json"} {
+		t.Run(ext, func(t *testing.T) {
+			cfgFile := filepath.Join(t.TempDir(), "config."+ext)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, len(saveValues))
+			for name := range saveValues {
+				wg.Go(func() {
+					cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentSaves$")
+					cmd.Env = append(os.Environ(),
+						"CONFIG_TEST_SAVE_FIELD="+name,
+						"CONFIG_TEST_SAVE_FILE="+cfgFile,
+					)
+					if out, err := cmd.CombinedOutput(); err != nil {
+						errs <- fmt.Errorf("saving %s: %w\n%s", name, err, out)
+					}
+				})
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+
+			data, err := os.ReadFile(cfgFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			settings, err := decodeSettings(ext, data)
+			if err != nil {
+				t.Fatalf("invalid config file: %v\n%s", err, data)
+			}
+			got := flattenSettings(settings, "")
+			for name := range saveValues {
+				if _, ok := got[name]; !ok {
+					t.Errorf("%s lost, config file:\n%s", name, data)
+				}
+			}
+
+			entries, err := History(cfgFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(saveValues) {
+				t.Errorf("got %d history entries, want %d", len(entries), len(saveValues))
+			}
+		})
+	}
+}
+
+// saveTestField saves the value of a field in saveValues to a config file
+func saveTestField(t *testing.T, name, cfgFile string) {
+	f, ok := Fields.Map()[name]
+	if !ok {
+		t.Fatalf("unknown field %s", name)
+	}
+	if err := WriteField(f, saveValues[name]); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFile(cfgFile); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,26 +1,92 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 )
 
+// mu guards the global viper instance and the configuration state of this
+// package, which the watcher (see Watch) updates concurrently with readers.
+var mu sync.RWMutex
+
+// getRaw returns the resolved raw value of a configuration key
+func getRaw(key string) any {
+	mu.RLock()
+	defer mu.RUnlock()
+	return viper.Get(key)
+}
+
+// envKeyReplacer replaces dots with underscores in environment variable names
+var envKeyReplacer = strings.NewReplacer(".", "_")
+
+// EnvVarName returns the name of the environment variable bound to a field
+// (e.g. CONFAPP_LOG_LEVEL for log.level).
+func EnvVarName(f *Field) string {
+	return consts.ConfigEnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(f.Name))
+}
+
+// defaultWarn writes a warning to the standard logger
+func defaultWarn(err error) {
+	log.Printf("warning: %v", err)
+}
+
+// warn receives the warnings reported after Init, see WithWarningHandler
+var warn = defaultWarn
+
+// Option configures Init
+type Option func(*options)
+
+// options holds the settings applied by Options
+type options struct {
+	strict bool
+	warn   func(error)
+}
+
+// WithStrict enables strict mode, in which Init validates the resolved value of
+// every field and fails if any is invalid, or if config files or environment
+// variables set unknown keys. Strict mode can also be enabled with the strict
+// flag field (FieldFlagStrict).
+func WithStrict(strict bool) Option {
+	return func(o *options) {
+		o.strict = strict
+	}
+}
+
+// WithWarningHandler sets the function receiving warnings outside of strict
+// mode, such as unknown keys, and panics recovered from change subscribers.
+// By default warnings are written to the standard logger.
+func WithWarningHandler(warn func(error)) Option {
+	return func(o *options) {
+		o.warn = warn
+	}
+}
+
 // Init initializes the configuration system by setting up Viper with environment
 // variables, defaults, and optionally reading from a configuration file.
 // This function must be called before any configuration values are accessed.
-func Init() error {
+func Init(opts ...Option) error {
+	o := options{warn: defaultWarn}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	warn = o.warn
+
 	// Enable automatic environment variable binding
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix(consts.ConfigEnvPrefix)
-	// Replace dots with underscores in environment variable names
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetEnvKeyReplacer(envKeyReplacer)
 
 	// Set default values for all defined fields
 	for _, field := range Fields {
@@ -29,117 +95,409 @@ func Init() error {
 		}
 	}
 
-	// Validate and process config file if specified
-	cfgFile := viper.GetString(FieldFlagConfig.Name)
-	if err := FieldFlagConfig.Validate(cfgFile); err != nil {
+	// Validate the user config file if specified
+	if err := FieldFlagConfig.Validate(viper.GetString(FieldFlagConfig.Name)); err != nil {
 		return fmt.Errorf("config file validation failed: %w", err)
 	}
 
-	if cfgFile != "" {
-		if err := loadConfigFile(cfgFile); err != nil {
-			return fmt.Errorf("failed to load config file: %w", err)
+	// Load the config files of every scope, narrower scopes last
+	for _, file := range scopeFiles() {
+		configFiles = append(configFiles, file)
+		if err := loadConfigFile(file); err != nil {
+			return fmt.Errorf("failed to load %s config file: %w", file.Scope, err)
+		}
+	}
+
+	// Report unknown keys, and validate the resolved values in strict mode
+	unknown := UnknownKeys()
+	if o.strict || FieldFlagStrict.Value() {
+		var errs []error
+		for _, err := range unknown {
+			errs = append(errs, err)
+		}
+		if err := ValidateAll(); err != nil {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+		}
+	} else {
+		for _, err := range unknown {
+			o.warn(err)
 		}
 	}
 
 	return nil
 }
 
-// loadConfigFile loads configuration from the specified file path.
+// ValidateAll validates the resolved value of every registered field.
+// All failures are reported at once as ValidationErrors, each naming the field
+// and the source of its value.
+func ValidateAll() error {
+	var errs ValidationErrors
+	for _, field := range Fields {
+		if verr := field.check(getRaw(field.Name)); verr != nil {
+			verr.Source = SourceOf(field)
+			errs = append(errs, verr)
+		}
+	}
+	return errs.OrNil()
+}
+
+// fileLayer holds the settings read from a single configuration file
+type fileLayer struct {
+	Path       string         // Path of the file
+	Scope      Scope          // Scope of the file
+	IncludedBy string         // File including this one, or whose drop-in directory holds it
+	Settings   map[string]any // Nested settings read from the file
+}
+
+// source returns the source of the values read from the file
+func (l *fileLayer) source() Source {
+	return Source{Kind: SourceFile, Name: l.Path, Scope: l.Scope}
+}
+
+// mergedSettings returns a copy of the settings of the file to merge into the
+// configuration. Global flag keys are left out: they are unknown keys in
+// config files (see UnknownKeys), and must neither select the config files
+// nor change the mode of the application.
+func (l *fileLayer) mergedSettings() map[string]any {
+	settings := copySettings(l.Settings)
+	for _, f := range flagFields {
+		deleteSetting(settings, f.Name)
+	}
+	return settings
+}
+
+// configFiles lists the configuration files that are read, whether they
+// exist or not, in the order they are merged.
+var configFiles []configFile
+
+// fileLayers lists the configuration files merged into the configuration,
+// in the order they were merged (lowest precedence first).
+var fileLayers []*fileLayer
+
+// loadConfigFile loads configuration from the specified file, along with the
+// files it includes and its drop-in fragments (see readConfigLayers).
 // The file extension determines the format (yaml, json, toml, etc.).
-func loadConfigFile(cfgFile string) error {
-	cfgFileDir := path.Dir(cfgFile)
-	cfgFileBase := path.Base(cfgFile)
-	cfgFileExt := path.Ext(cfgFile)
-	cfgFileName := strings.TrimSuffix(cfgFileBase, cfgFileExt)
-
-	viper.SetConfigName(cfgFileName)
-	viper.SetConfigType(cfgFileExt[1:])
-	viper.AddConfigPath(cfgFileDir)
-
-	// Attempt to read the config file, but don't fail if it doesn't exist
-	if err := viper.ReadInConfig(); err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) {
-		return fmt.Errorf("failed to read config file: %w", err)
+// A missing file is not an error.
+func loadConfigFile(file configFile) error {
+	layers, err := readConfigLayers(file)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, layer := range layers {
+		if err := viper.MergeConfigMap(layer.mergedSettings()); err != nil {
+			return fmt.Errorf("failed to merge config file %s: %w", layer.Path, err)
+		}
+		fileLayers = append(fileLayers, layer)
 	}
 
 	return nil
 }
 
+// readConfigFile reads the settings of a configuration file.
+// Returns nil if the file does not exist.
+func readConfigFile(file configFile) (*fileLayer, error) {
+	v := viper.New()
+	v.SetConfigFile(file.Path)
+
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return &fileLayer{Path: file.Path, Scope: file.Scope, Settings: v.AllSettings()}, nil
+}
+
+// copySettings returns a deep copy of nested settings.
+// Viper keeps references to the maps it merges, so layers are copied to
+// remain unaffected by later merges.
+func copySettings(settings map[string]any) map[string]any {
+	out := make(map[string]any, len(settings))
+	for key, val := range settings {
+		if child, ok := val.(map[string]any); ok {
+			val = copySettings(child)
+		}
+		out[key] = val
+	}
+	return out
+}
+
 // ReadField retrieves the current value of a configuration field.
 // Returns the value as an interface{} type.
 func ReadField(f *Field) any {
-	return viper.Get(f.Name)
+	return getRaw(f.Name)
+}
+
+// ReadTyped retrieves the current value of a configuration field converted to
+// the Go type of its FieldType (see FieldValue).
+// Returns nil if the field has no value, or an error if the conversion fails.
+func ReadTyped(f *Field) (any, error) {
+	return readTyped(f, getRaw(f.Name))
 }
 
 // ReadFieldString retrieves the current value of a configuration field as a string.
+// Returns the zero value if the value cannot be converted.
+//
+// Deprecated: Use the field's TypedField.Get or Value methods, or Get.
 func ReadFieldString(f *Field) string {
-	return viper.GetString(f.Name)
+	return cast.ToString(getRaw(f.Name))
 }
 
 // ReadFieldBool retrieves the current value of a configuration field as a boolean.
+// Returns the zero value if the value cannot be converted.
+//
+// Deprecated: Use the field's TypedField.Get or Value methods, or Get.
 func ReadFieldBool(f *Field) bool {
-	return viper.GetBool(f.Name)
+	return cast.ToBool(getRaw(f.Name))
 }
 
 // ReadFieldInt retrieves the current value of a configuration field as an integer.
+// Returns the zero value if the value cannot be converted.
+//
+// Deprecated: Use the field's TypedField.Get or Value methods, or Get.
 func ReadFieldInt(f *Field) int {
-	return viper.GetInt(f.Name)
+	return cast.ToInt(getRaw(f.Name))
 }
 
 // ReadFieldDuration retrieves the current value of a configuration field as a duration.
+// Returns the zero value if the value cannot be converted.
+//
+// Deprecated: Use the field's TypedField.Get or Value methods, or Get.
 func ReadFieldDuration(f *Field) time.Duration {
-	return viper.GetDuration(f.Name)
+	return cast.ToDuration(getRaw(f.Name))
 }
 
+// setKeys holds the keys set at runtime with WriteField
+var setKeys = make(map[string]struct{})
+
+// unsetKeys holds the keys removed with UnsetField, which are left out
+// of the configuration file on the next Save.
+var unsetKeys = make(map[string]struct{})
+
 // WriteField sets a configuration field value after validating it.
-// The value is validated using the field's validation rules before being set.
+// The value is validated using the field's validation rules and must convert
+// to the field type, like values read from files (see ValidateAll); a
+// *ValidationError is returned if it is invalid. Change subscribers are
+// notified if the resolved value changes.
 func WriteField(f *Field, value any) error {
-	if err := f.Validate(value); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	if verr := f.check(value); verr != nil {
+		return verr
 	}
 
+	mu.Lock()
+	old := viper.Get(f.Name)
 	viper.Set(f.Name, value)
+	setKeys[f.Name] = struct{}{}
+	delete(unsetKeys, f.Name)
+	mu.Unlock()
+
+	if !sameValue(f, old, value) {
+		notify([]Change{newChange(f, old, value)})
+	}
+	return nil
+}
+
+// UnsetField removes a configuration field from the config file on the next
+// Save, so that it falls back to its default value.
+// The default value is validated using the field's validation rules first.
+func UnsetField(f *Field) error {
+	if err := f.Validate(f.Default); err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			verr.Source = Source{Kind: SourceDefault}
+		}
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	unsetKeys[f.Name] = struct{}{}
 	return nil
 }
 
-// Save writes the current configuration to the specified config file.
-// Creates the directory structure if it doesn't exist.
+// Save writes the configuration changes to the user config file.
+// See SaveScope.
 func Save() error {
-	cfgFile := viper.GetString(FieldFlagConfig.Name)
+	return SaveScope(ScopeUser)
+}
+
+// SaveScope writes the configuration changes to the config file of a scope.
+// See SaveFile. Fails for the user scope if several user config files are
+// specified, since the file to write must then be designated with SaveFile.
+func SaveScope(scope Scope) error {
+	if n := len(UserConfigFiles()); scope == ScopeUser && n > 1 {
+		return fmt.Errorf("%d user config files are specified, designate the one to write", n)
+	}
+
+	cfgFile := ScopeFile(scope)
 	if cfgFile == "" {
-		return fmt.Errorf("no config file specified")
+		return fmt.Errorf("no config file specified for scope %s", scope)
 	}
+	return saveFile(configFile{Path: cfgFile, Scope: scope})
+}
 
-	// Try to write the config file
-	if err := viper.WriteConfigAs(cfgFile); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			// Create directory structure and file if they don't exist
-			return createAndWriteConfigFile(cfgFile)
-		}
+// SaveFile writes the configuration changes to the given config file.
+// The values set with WriteField are added to the settings of the file, and
+// keys removed with UnsetField are left out. Settings of other files, default
+// values and global flag fields are not written. YAML and TOML files are
+// edited in place, so that their comments, blank lines and key order are
+// kept. The previous content is recorded in the history of the file (see
+// History). Creates the directory structure if it doesn't exist.
+func SaveFile(cfgFile string) error {
+	if err := FieldFlagConfig.Validate(cfgFile); err != nil {
+		return err
+	}
+	return saveFile(configFile{Path: cfgFile})
+}
+
+// saveFile writes the configuration changes to a config file
+func saveFile(file configFile) error {
+	cfgFile := file.Path
+
+	// Hold the lock from reading the file until it is replaced, so that
+	// concurrent saves apply their changes one after the other
+	if err := os.MkdirAll(filepath.Dir(cfgFile), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	unlock, err := lockConfigFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(cfgFile)
+	existed := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	out, err := editConfigData(cfgFile, data, pendingEdits())
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(cfgFile, out, 0644); err != nil {
 		return fmt.Errorf("failed to write config file %s: %w", cfgFile, err)
 	}
 
+	// The change is saved even if its history cannot be recorded
+	if err := recordHistory(cfgFile, data, existed, out); err != nil {
+		warn(fmt.Errorf("failed to record config history: %w", err))
+	}
+
 	return nil
 }
 
+// CreateConfigFile creates an empty config file, along with its directory
+// structure, if it does not exist.
+func CreateConfigFile(cfgFile string) error {
+	if _, err := os.Stat(cfgFile); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return createAndWriteConfigFile(cfgFile, nil)
+}
+
 // createAndWriteConfigFile creates the directory structure and config file,
-// then writes the current configuration to it.
-func createAndWriteConfigFile(cfgFile string) error {
+// then writes the settings to it.
+func createAndWriteConfigFile(cfgFile string, settings map[string]any) error {
 	// Create the directory structure
-	if err := os.MkdirAll(path.Dir(cfgFile), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(cfgFile), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Create an empty config file
-	if f, err := os.Create(cfgFile); err != nil {
-		return fmt.Errorf("failed to create config file %s: %w", cfgFile, err)
-	} else {
-		f.Close()
-	}
-
 	// Write the configuration to the file
-	if err := viper.WriteConfigAs(cfgFile); err != nil {
+	if err := writeConfigFile(cfgFile, settings); err != nil {
 		return fmt.Errorf("failed to write configuration: %w", err)
 	}
 
 	return nil
 }
+
+// writeConfigFile atomically replaces a config file with nested settings.
+// The file extension determines the format. An empty file is written for
+// empty settings, as a valid empty configuration, except in JSON.
+func writeConfigFile(cfgFile string, settings map[string]any) error {
+	var data []byte
+	if len(settings) > 0 || configFileType(cfgFile) == "json" {
+		var err error
+		if data, err = encodeSettings(configFileType(cfgFile), settings); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(cfgFile, data, 0644)
+}
+
+// encodeSettings encodes nested settings in a config file format
+func encodeSettings(format string, settings map[string]any) ([]byte, error) {
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := v.WriteConfigTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// persistedValue converts a value set at runtime to the field type for writing
+// it to a config file. Durations are written in their string form.
+func persistedValue(f *Field, value any) any {
+	if f == nil {
+		return value
+	}
+	typed, err := readTyped(f, value)
+	if err != nil {
+		return value
+	}
+	if d, ok := typed.(time.Duration); ok {
+		return formatDuration(d)
+	}
+	return typed
+}
+
+// setSetting sets a dotted key in nested settings,
+// creating parent maps as needed.
+func setSetting(settings map[string]any, key string, value any) {
+	parent, leaf, found := strings.Cut(key, ".")
+	if !found {
+		settings[key] = value
+		return
+	}
+
+	child, ok := settings[parent].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		settings[parent] = child
+	}
+	setSetting(child, leaf, value)
+}
+
+// deleteSetting removes a dotted key from nested settings,
+// along with any parent maps left empty.
+func deleteSetting(settings map[string]any, key string) {
+	parent, leaf, found := strings.Cut(key, ".")
+	if !found {
+		delete(settings, key)
+		return
+	}
+
+	child, ok := settings[parent].(map[string]any)
+	if !ok {
+		return
+	}
+	deleteSetting(child, leaf)
+	if len(child) == 0 {
+		delete(settings, parent)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestWriteFieldType checks that WriteField rejects values that cannot be
+// converted to the field type, like ValidateAll does
+func TestWriteFieldType(t *testing.T) {
+	tests := []struct {
+		field *Field
+		value any
+		rule  string
+	}{
+		{FieldAppUpdateAuto.Field, "maybe", RuleType},
+		{FieldAppUpdatePeriod.Field, "soon", RuleValidateFunc},
+		{FieldAppLogLevel.Field, "loud", RuleValidValues},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field.Name, func(t *testing.T) {
+			err := WriteField(tt.field, tt.value)
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("WriteField(%q) = %v, want a *ValidationError", tt.value, err)
+			}
+			if verr.Rule != tt.rule {
+				t.Errorf("rule = %q, want %q", verr.Rule, tt.rule)
+			}
+			if _, ok := setKeys[tt.field.Name]; ok {
+				t.Errorf("invalid value %q was set", tt.value)
+			}
+		})
+	}
+}
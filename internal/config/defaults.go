@@ -47,6 +47,21 @@ func defaultInt(val string) any {
 	return i
 }
 
+// defaultFloat converts a string to a float default value.
+// Returns nil if the string is empty, panics on invalid floats.
+func defaultFloat(val string) any {
+	if val == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		log.Fatalf("Invalid default float value: %s (%v)", val, err)
+	}
+
+	return f
+}
+
 // defaultDuration converts a string to a duration default value.
 // Returns nil if the string is empty, panics on invalid duration strings.
 // Supports Go duration format (e.g., "1h30m", "15m", "10s").
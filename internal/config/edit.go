@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// settingEdit is a change of a single key to be written to a config file
+type settingEdit struct {
+	Key    string
+	Value  any
+	Delete bool
+}
+
+// errUnsupportedEdit is returned by the format editors when an edit cannot be
+// applied in place, in which case the whole file is rewritten instead.
+var errUnsupportedEdit = errors.New("construct not editable in place")
+
+// pendingEdits returns the edits of the values set with WriteField and the
+// keys removed with UnsetField, in a deterministic order.
+// Only explicitly set values are written, never defaults, so that later
+// changes to the defaults apply to existing config files. Global flag fields
+// are not settings: they are never written, and are removed from files saved
+// by older versions, which wrote the whole merged configuration.
+func pendingEdits() []settingEdit {
+	fields, flags := Fields.Map(), flagFields.Map()
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var edits []settingEdit
+	for _, key := range slices.Sorted(maps.Keys(setKeys)) {
+		if _, ok := flags[key]; !ok {
+			edits = append(edits, settingEdit{Key: key, Value: persistedValue(fields[key], viper.Get(key))})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(unsetKeys)) {
+		edits = append(edits, settingEdit{Key: key, Delete: true})
+	}
+	for _, f := range flagFields {
+		edits = append(edits, settingEdit{Key: f.Name, Delete: true})
+	}
+	return edits
+}
+
+// editConfigData applies edits to the content of a config file.
+// YAML and TOML files are edited in place: only the lines of the edited keys
+// change, and comments, blank lines and key order are kept. Other formats are
+// decoded and written again as a whole, as are documents using constructs the
+// editors do not handle, with a warning since their comments and formatting
+// are lost.
+func editConfigData(cfgFile string, data []byte, edits []settingEdit) ([]byte, error) {
+	format := configFileType(cfgFile)
+	settings, err := decodeSettings(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	for _, edit := range edits {
+		if edit.Delete {
+			deleteSetting(settings, edit.Key)
+		} else {
+			setSetting(settings, edit.Key, edit.Value)
+		}
+	}
+
+	var editor func([]string, []settingEdit) ([]string, error)
+	switch format {
+	case "yaml", "yml":
+		editor = editYAML
+	case "toml":
+		editor = editTOML
+	}
+	if editor != nil {
+		out, err := editLines(data, edits, editor)
+		if err == nil {
+			// The edited document must hold exactly the expected settings,
+			// otherwise it is safer to rewrite it
+			got, derr := decodeSettings(format, out)
+			if derr == nil && sameSettings(got, settings) {
+				return out, nil
+			}
+			err = errors.New("the edited document does not hold the expected settings")
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			warn(fmt.Errorf("cannot edit %s in place, rewriting it without its comments and formatting: %w", cfgFile, err))
+		}
+	}
+
+	if len(settings) == 0 && format != "json" {
+		return nil, nil
+	}
+	return encodeSettings(format, settings)
+}
+
+// editLines applies an editor to the lines of a document, keeping its line
+// endings.
+func editLines(data []byte, edits []settingEdit, editor func([]string, []settingEdit) ([]string, error)) ([]byte, error) {
+	text := string(data)
+	crlf := strings.Contains(text, "\r\n")
+	if crlf {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+
+	var lines []string
+	if text != "" {
+		lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+	lines, err := editor(lines, edits)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	text = strings.Join(lines, "\n") + "\n"
+	if crlf {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return []byte(text), nil
+}
+
+// decodeSettings decodes the nested settings of a config file.
+// Empty content decodes to empty settings.
+func decodeSettings(format string, data []byte) (map[string]any, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return make(map[string]any), nil
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// sameSettings reports whether two nested settings hold the same keys and
+// values. Values are compared by their formatting, since decoders differ in
+// the Go types they use for numbers.
+func sameSettings(a, b map[string]any) bool {
+	fa, fb := flattenSettings(a, ""), flattenSettings(b, "")
+	return maps.Equal(fa, fb)
+}
+
+// flattenSettings maps the dotted keys of nested settings to their formatted values
+func flattenSettings(settings map[string]any, prefix string) map[string]string {
+	out := make(map[string]string)
+	for key, val := range settings {
+		if child, ok := val.(map[string]any); ok {
+			maps.Copy(out, flattenSettings(child, prefix+key+"."))
+			continue
+		}
+		out[prefix+key] = fmt.Sprint(val)
+	}
+	return out
+}
+
+// leadingSpace returns the indentation of a line
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// dropDoubleBlankLine removes the blank line before index i of lines if the
+// line at i is blank too or the end of the document, after lines were removed
+// between them
+func dropDoubleBlankLine(lines []string, i int) []string {
+	if i > 0 && i <= len(lines) && strings.TrimSpace(lines[i-1]) == "" &&
+		(i == len(lines) || strings.TrimSpace(lines[i]) == "") {
+		return slices.Delete(lines, i-1, i)
+	}
+	return lines
+}
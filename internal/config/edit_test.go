@@ -0,0 +1,249 @@
+package config
+
+import "testing"
+
+func TestEditConfigData(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		in       string
+		edits    []settingEdit
+		want     string
+		fallback bool // whether the file is rewritten as a whole, with a warning
+	}{
+		// YAML
+		{
+			name: "yaml replace keeps comments",
+			file: "config.yaml",
+			in: "# application\n" +
+				"log:\n" +
+				"  # verbosity\n" +
+				"  level: info   # default is info\n" +
+				"\n" +
+				"  format: text\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}},
+			want: "# application\n" +
+				"log:\n" +
+				"  # verbosity\n" +
+				"  level: warn   # default is info\n" +
+				"\n" +
+				"  format: text\n",
+		},
+		{
+			name:  "yaml replace quoted value",
+			file:  "config.yaml",
+			in:    "log:\n  level: \"info\" # quoted\n  format: 'text'\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}, {Key: "log.format", Value: "json"}},
+			want:  "log:\n  level: warn # quoted\n  format: json\n",
+		},
+		{
+			name:  "yaml insert into mapping",
+			file:  "config.yaml",
+			in:    "log:\n  level: info # level\n# end\n",
+			edits: []settingEdit{{Key: "log.format", Value: "json"}},
+			want:  "log:\n  level: info # level\n  format: json\n# end\n",
+		},
+		{
+			name:  "yaml insert mapping",
+			file:  "config.yaml",
+			in:    "log:\n    level: info\n",
+			edits: []settingEdit{{Key: "proxy.http", Value: "http://proxy:8080"}},
+			want:  "log:\n    level: info\nproxy:\n    http: http://proxy:8080\n",
+		},
+		{
+			name:  "yaml insert into empty file",
+			file:  "config.yaml",
+			in:    "",
+			edits: []settingEdit{{Key: "update.auto", Value: true}},
+			want:  "update:\n  auto: true\n",
+		},
+		{
+			name:  "yaml remove mapping",
+			file:  "config.yaml",
+			in:    "log:\n  level: info\n\nproxy:\n  http: http://proxy:8080\n\n# end\n",
+			edits: []settingEdit{{Key: "proxy.http", Delete: true}},
+			want:  "log:\n  level: info\n\n# end\n",
+		},
+		{
+			name:  "yaml remove missing key",
+			file:  "config.yaml",
+			in:    "log:\n  level: info\n",
+			edits: []settingEdit{{Key: "log.format", Delete: true}},
+			want:  "log:\n  level: info\n",
+		},
+		{
+			name:  "yaml crlf",
+			file:  "config.yaml",
+			in:    "log:\r\n  level: info # level\r\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}, {Key: "log.format", Value: "json"}},
+			want:  "log:\r\n  level: warn # level\r\n  format: json\r\n",
+		},
+		{
+			name:  "yaml document markers",
+			file:  "config.yaml",
+			in:    "---\nlog:\n  level: info\n...\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}, {Key: "log.format", Value: "json"}},
+			want:  "---\nlog:\n  level: warn\n  format: json\n...\n",
+		},
+		{
+			name:     "yaml flow mapping",
+			file:     "config.yaml",
+			in:       "# comment\nlog: {level: info}\n",
+			edits:    []settingEdit{{Key: "log.level", Value: "warn"}},
+			want:     "log:\n    level: warn\n",
+			fallback: true,
+		},
+		{
+			name:     "yaml multi-line value",
+			file:     "config.yaml",
+			in:       "log:\n  output: |\n    /var/log/app.log\n",
+			edits:    []settingEdit{{Key: "log.output", Value: "/tmp/app.log"}},
+			want:     "log:\n    output: /tmp/app.log\n",
+			fallback: true,
+		},
+
+		// TOML
+		{
+			name: "toml replace keeps comments",
+			file: "config.toml",
+			in: "# application\n" +
+				"[log]\n" +
+				"# verbosity\n" +
+				"level = \"info\"   # default is info\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}},
+			want: "# application\n" +
+				"[log]\n" +
+				"# verbosity\n" +
+				"level = \"warn\"   # default is info\n",
+		},
+		{
+			name:  "toml replace literal string",
+			file:  "config.toml",
+			in:    "[log]\nlevel = 'info'\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}},
+			want:  "[log]\nlevel = \"warn\"\n",
+		},
+		{
+			name:  "toml escape string",
+			file:  "config.toml",
+			in:    "",
+			edits: []settingEdit{{Key: "environment", Value: "a \"b\" \\c\t"}},
+			want:  "environment = \"a \\\"b\\\" \\\\c\\t\"\n",
+		},
+		{
+			name:  "toml replace non-string values",
+			file:  "config.toml",
+			in:    "[update]\nauto = false\nperiod = \"15m\"\n",
+			edits: []settingEdit{{Key: "update.auto", Value: true}, {Key: "update.period", Value: "1h"}},
+			want:  "[update]\nauto = true\nperiod = \"1h\"\n",
+		},
+		{
+			name:  "toml dotted keys",
+			file:  "config.toml",
+			in:    "log.level = \"info\" # level\nproxy.http = \"http://proxy:8080\"\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}, {Key: "proxy.http", Delete: true}},
+			want:  "log.level = \"warn\" # level\n",
+		},
+		{
+			name:     "toml dotted keys insert",
+			file:     "config.toml",
+			in:       "# comment\nlog.level = \"info\"\n",
+			edits:    []settingEdit{{Key: "log.format", Value: "json"}},
+			want:     "[log]\nformat = 'json'\nlevel = 'info'\n",
+			fallback: true,
+		},
+		{
+			name:  "toml insert into table",
+			file:  "config.toml",
+			in:    "[log]\nlevel = \"info\"\n\n[proxy]\nhttp = \"http://proxy:8080\"\n",
+			edits: []settingEdit{{Key: "log.format", Value: "json"}},
+			want:  "[log]\nlevel = \"info\"\nformat = \"json\"\n\n[proxy]\nhttp = \"http://proxy:8080\"\n",
+		},
+		{
+			name:  "toml insert into empty table",
+			file:  "config.toml",
+			in:    "[log]\n[proxy]\nhttp = \"http://proxy:8080\"\n",
+			edits: []settingEdit{{Key: "log.format", Value: "json"}},
+			want:  "[log]\nformat = \"json\"\n\n[proxy]\nhttp = \"http://proxy:8080\"\n",
+		},
+		{
+			name:  "toml insert top-level key",
+			file:  "config.toml",
+			in:    "# application\n[log]\nlevel = \"info\"\n",
+			edits: []settingEdit{{Key: "environment", Value: "prod"}},
+			want:  "# application\nenvironment = \"prod\"\n\n[log]\nlevel = \"info\"\n",
+		},
+		{
+			name:  "toml insert table",
+			file:  "config.toml",
+			in:    "[log]\nlevel = \"info\"\n",
+			edits: []settingEdit{{Key: "proxy.http", Value: "http://proxy:8080"}},
+			want:  "[log]\nlevel = \"info\"\n\n[proxy]\nhttp = \"http://proxy:8080\"\n",
+		},
+		{
+			name:  "toml remove table",
+			file:  "config.toml",
+			in:    "[log]\nlevel = \"info\"\n\n[proxy]\nhttp = \"http://proxy:8080\"\n\n[update]\nauto = true\n",
+			edits: []settingEdit{{Key: "proxy.http", Delete: true}},
+			want:  "[log]\nlevel = \"info\"\n\n[update]\nauto = true\n",
+		},
+		{
+			name:  "toml crlf",
+			file:  "config.toml",
+			in:    "[log]\r\nlevel = \"info\" # level\r\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}, {Key: "log.format", Value: "json"}},
+			want:  "[log]\r\nlevel = \"warn\" # level\r\nformat = \"json\"\r\n",
+		},
+		{
+			name:     "toml array of tables",
+			file:     "config.toml",
+			in:       "[[servers]]\nname = \"a\"\n",
+			edits:    []settingEdit{{Key: "log.level", Value: "warn"}},
+			want:     "[log]\nlevel = 'warn'\n\n[[servers]]\nname = 'a'\n",
+			fallback: true,
+		},
+
+		// Other formats are always rewritten
+		{
+			name:  "json",
+			file:  "config.json",
+			in:    "{\"log\": {\"level\": \"info\"}}\n",
+			edits: []settingEdit{{Key: "log.level", Value: "warn"}},
+			want:  "{\n  \"log\": {\n    \"level\": \"warn\"\n  }\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []error
+			warn = func(err error) { warnings = append(warnings, err) }
+			t.Cleanup(func() { warn = defaultWarn })
+
+			got, err := editConfigData(tt.file, []byte(tt.in), tt.edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if fallback := len(warnings) > 0; fallback != tt.fallback {
+				t.Errorf("fallback = %v, want %v (warnings: %v)", fallback, tt.fallback, warnings)
+			}
+		})
+	}
+}
+
+// TestPendingEditsFlagKeys checks that saves remove the global flag keys
+// written by older versions, and keep the other settings
+func TestPendingEditsFlagKeys(t *testing.T) {
+	in := "config: /etc/confapp.yaml\nverbose: true\nlog:\n  level: info # level\n"
+	want := "log:\n  level: info # level\n"
+
+	got, err := editConfigData("config.yaml", []byte(in), pendingEdits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
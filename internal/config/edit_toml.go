@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// tomlHeader is a table header line of a TOML document
+type tomlHeader struct {
+	Line int    // index of the line
+	Name string // lower-case dotted name
+}
+
+// tomlEntry is a key/value line of a TOML document
+type tomlEntry struct {
+	Line  int    // index of the line
+	Table string // lower-case dotted name of the enclosing table
+	Key   string // lower-case dotted key, including the table
+	Value int    // offset of the value in the line
+}
+
+// editTOML applies edits to the lines of a TOML document.
+// Only the lines of the edited keys are replaced, inserted or removed; new
+// keys are added to their table, which is appended to the document if it does
+// not exist. Returns errUnsupportedEdit for constructs that cannot be edited
+// line by line, such as arrays of tables, quoted keys or multi-line values.
+func editTOML(lines []string, edits []settingEdit) ([]string, error) {
+	for _, edit := range edits {
+		var err error
+		if lines, err = editTOMLKey(lines, edit); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// editTOMLKey applies a single edit to the lines of a TOML document
+func editTOMLKey(lines []string, edit settingEdit) ([]string, error) {
+	tables, entries, err := parseTOMLLines(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	if i := slices.IndexFunc(entries, func(e tomlEntry) bool { return e.Key == edit.Key }); i >= 0 {
+		entry := entries[i]
+		if edit.Delete {
+			return deleteTOMLEntry(lines, tables, entries, entry), nil
+		}
+
+		value, err := tomlValue(edit.Value)
+		if err != nil {
+			return nil, err
+		}
+		line := lines[entry.Line]
+		n := tomlValueLen(line[entry.Value:])
+		lines[entry.Line] = line[:entry.Value] + value + line[entry.Value+n:]
+		return lines, nil
+	}
+	if edit.Delete {
+		return lines, nil
+	}
+
+	value, err := tomlValue(edit.Value)
+	if err != nil {
+		return nil, err
+	}
+	table, leaf := "", edit.Key
+	if i := strings.LastIndex(edit.Key, "."); i >= 0 {
+		table, leaf = edit.Key[:i], edit.Key[i+1:]
+	}
+
+	// Add the key after the last entry of its table
+	header := slices.IndexFunc(tables, func(t tomlHeader) bool { return t.Name == table })
+	if table == "" || header >= 0 {
+		at, indent := len(lines), ""
+		switch {
+		case table != "":
+			at = tables[header].Line + 1
+		case len(tables) > 0:
+			at = tables[0].Line
+		}
+		for _, e := range entries {
+			if e.Table == table {
+				at, indent = e.Line+1, leadingSpace(lines[e.Line])
+			}
+		}
+
+		add := []string{indent + leaf + " = " + value}
+		if at < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[at]), "[") {
+			add = append(add, "")
+		}
+		return slices.Insert(lines, at, add...), nil
+	}
+
+	// A table defined by dotted keys or sub-tables cannot be opened again
+	prefix := table + "."
+	for _, e := range entries {
+		if strings.HasPrefix(e.Key, prefix) {
+			return nil, errUnsupportedEdit
+		}
+	}
+	for _, t := range tables {
+		if strings.HasPrefix(t.Name, prefix) {
+			return nil, errUnsupportedEdit
+		}
+	}
+
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+		lines = append(lines, "")
+	}
+	return append(lines, "["+table+"]", leaf+" = "+value), nil
+}
+
+// deleteTOMLEntry removes the line of an entry, along with the header of its
+// table if no other entry is left in it
+func deleteTOMLEntry(lines []string, tables []tomlHeader, entries []tomlEntry, entry tomlEntry) []string {
+	lines = dropDoubleBlankLine(slices.Delete(lines, entry.Line, entry.Line+1), entry.Line)
+	if entry.Table == "" || slices.ContainsFunc(entries, func(e tomlEntry) bool {
+		return e.Table == entry.Table && e.Line != entry.Line
+	}) {
+		return lines
+	}
+
+	i := slices.IndexFunc(tables, func(t tomlHeader) bool { return t.Name == entry.Table })
+	if i < 0 {
+		return lines
+	}
+	h := tables[i].Line
+	return dropDoubleBlankLine(slices.Delete(lines, h, h+1), h)
+}
+
+// parseTOMLLines locates the table headers and key/value lines of a TOML
+// document, like tomlKeyPositions. Returns errUnsupportedEdit if the document
+// uses constructs that span several lines or that cannot be matched to setting
+// keys.
+func parseTOMLLines(lines []string) ([]tomlHeader, []tomlEntry, error) {
+	var (
+		tables  []tomlHeader
+		entries []tomlEntry
+		table   string
+	)
+	for i, line := range lines {
+		s := strings.TrimSpace(line)
+		switch {
+		case s == "" || strings.HasPrefix(s, "#"):
+		case strings.HasPrefix(s, "[["):
+			return nil, nil, errUnsupportedEdit
+		default:
+			if m := tomlTable.FindStringSubmatch(line); m != nil {
+				table = strings.ToLower(m[1])
+				tables = append(tables, tomlHeader{Line: i, Name: table})
+				continue
+			}
+			m := tomlKey.FindStringSubmatch(line)
+			if m == nil {
+				return nil, nil, errUnsupportedEdit
+			}
+			key := strings.ToLower(m[2])
+			value := len(m[0])
+			rest := line[value:]
+			if strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''") || tomlValueLen(rest) < 0 {
+				return nil, nil, errUnsupportedEdit
+			}
+			if table != "" {
+				key = table + "." + key
+			}
+			entries = append(entries, tomlEntry{Line: i, Table: table, Key: key, Value: value})
+		}
+	}
+	return tables, entries, nil
+}
+
+// tomlValueLen returns the length of the value at the start of s, excluding
+// any trailing comment, or -1 if the value does not end on the line.
+func tomlValueLen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(s) {
+				return -1
+			}
+		case '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return -1
+			}
+			i += j + 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '#':
+			if depth != 0 {
+				return -1
+			}
+			return len(strings.TrimRight(s[:i], " \t"))
+		}
+	}
+	if depth != 0 {
+		return -1
+	}
+	return len(strings.TrimRight(s, " \t"))
+}
+
+// tomlValue formats a value as a single-line TOML value.
+// Strings are written as basic (double-quoted) strings, which config files
+// use far more often than the literal strings go-toml prefers.
+func tomlValue(value any) (string, error) {
+	if s, ok := value.(string); ok {
+		return tomlBasicString(s), nil
+	}
+	b, err := toml.Marshal(map[string]any{"v": value})
+	if err != nil {
+		return "", err
+	}
+	s, ok := strings.CutPrefix(strings.TrimSuffix(string(b), "\n"), "v = ")
+	if !ok || strings.Contains(s, "\n") {
+		return "", errUnsupportedEdit
+	}
+	return s, nil
+}
+
+// tomlBasicString quotes a string as a TOML basic string
+func tomlBasicString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
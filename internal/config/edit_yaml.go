@@ -0,0 +1,229 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultYAMLIndent is the indentation used for new nested keys when the
+// document has none to follow
+const defaultYAMLIndent = 2
+
+// editYAML applies edits to the lines of a YAML document.
+// The document is parsed to locate the edited keys, and only their lines are
+// replaced, inserted or removed. Returns errUnsupportedEdit for constructs
+// that cannot be edited line by line, such as flow mappings, anchors or
+// multi-line values.
+func editYAML(lines []string, edits []settingEdit) ([]string, error) {
+	for _, edit := range edits {
+		var err error
+		if lines, err = editYAMLKey(lines, edit); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// editYAMLKey applies a single edit to the lines of a YAML document
+func editYAMLKey(lines []string, edit settingEdit) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &doc); err != nil {
+		return nil, err
+	}
+
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+		if len(doc.Content) > 1 || !isBlockMapping(root) {
+			return nil, errUnsupportedEdit
+		}
+	}
+
+	// Follow the path of the key as far as it exists
+	path := strings.Split(edit.Key, ".")
+	var keys, values []*yaml.Node
+	for node := root; node != nil && len(keys) < len(path); {
+		if !isBlockMapping(node) {
+			return nil, errUnsupportedEdit
+		}
+		k, v := yamlMappingEntry(node, path[len(keys)])
+		if k == nil {
+			break
+		}
+		keys, values = append(keys, k), append(values, v)
+		node = v
+	}
+
+	if len(keys) == len(path) {
+		if edit.Delete {
+			return deleteYAMLEntry(lines, keys, values), nil
+		}
+		return replaceYAMLValue(lines, keys[len(keys)-1], values[len(values)-1], edit.Value)
+	}
+	if edit.Delete {
+		return lines, nil
+	}
+
+	// Insert the missing part of the path after the last entry of the
+	// deepest existing mapping
+	at, indent := len(lines), 0
+	if len(keys) > 0 {
+		parent := values[len(values)-1]
+		if !isBlockMapping(parent) {
+			return nil, errUnsupportedEdit
+		}
+		at, indent = yamlEndLine(parent), parent.Content[0].Column-1
+	} else if root != nil {
+		at = yamlEndLine(root)
+	}
+
+	value, err := yamlScalar(edit.Value)
+	if err != nil {
+		return nil, err
+	}
+	step := yamlIndentStep(root)
+	missing := path[len(keys):]
+	add := make([]string, len(missing))
+	for i, name := range missing {
+		add[i] = strings.Repeat(" ", indent+i*step) + name + ":"
+		if i == len(missing)-1 {
+			add[i] += " " + value
+		}
+	}
+	return slices.Insert(lines, at, add...), nil
+}
+
+// replaceYAMLValue replaces the scalar value of a key on its line, keeping
+// any comment that follows it
+func replaceYAMLValue(lines []string, key, node *yaml.Node, value any) ([]string, error) {
+	if node.Kind != yaml.ScalarNode || node.Line != key.Line || node.Anchor != "" ||
+		node.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.TaggedStyle) != 0 {
+		return nil, errUnsupportedEdit
+	}
+
+	line := lines[node.Line-1]
+	start := node.Column - 1
+	if start < 0 || start > len(line) {
+		return nil, errUnsupportedEdit
+	}
+	n := yamlScalarLen(line[start:], node)
+	if n < 0 {
+		return nil, errUnsupportedEdit
+	}
+
+	s, err := yamlScalar(value)
+	if err != nil {
+		return nil, err
+	}
+	lines[node.Line-1] = line[:start] + s + line[start+n:]
+	return lines, nil
+}
+
+// deleteYAMLEntry removes the lines of a key and its value, along with the
+// parent keys that would be left without entries
+func deleteYAMLEntry(lines []string, keys, values []*yaml.Node) []string {
+	i := len(keys) - 1
+	for i > 0 && len(values[i-1].Content) == 2 {
+		i--
+	}
+	start := keys[i].Line - 1
+	return dropDoubleBlankLine(slices.Delete(lines, start, yamlEndLine(values[i])), start)
+}
+
+// yamlScalarLen returns the length of the source text of a single-line scalar
+// at the start of s, or -1 if it does not end on that line.
+func yamlScalarLen(s string, node *yaml.Node) int {
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return -1
+	default:
+		end := len(s)
+		if i := strings.Index(s, " #"); i >= 0 {
+			end = i
+		}
+		text := strings.TrimRight(s[:end], " \t")
+		if text != node.Value {
+			// Plain scalars continued on the next lines are folded
+			return -1
+		}
+		return len(text)
+	}
+}
+
+// yamlScalar formats a value as a single-line YAML scalar
+func yamlScalar(value any) (string, error) {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(b), "\n")
+	if strings.Contains(s, "\n") {
+		return "", errUnsupportedEdit
+	}
+	return s, nil
+}
+
+// yamlMappingEntry returns the key and value nodes of a mapping entry,
+// matching the key case-insensitively like viper.
+func yamlMappingEntry(mapping *yaml.Node, name string) (key, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, name) {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// yamlEndLine returns the last line spanned by a node, 1-based
+func yamlEndLine(node *yaml.Node) int {
+	end := node.Line
+	if node.Kind == yaml.ScalarNode && node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 && node.Value != "" {
+		end += strings.Count(strings.TrimRight(node.Value, "\n"), "\n") + 1
+	}
+	for _, child := range node.Content {
+		end = max(end, yamlEndLine(child))
+	}
+	return end
+}
+
+// yamlIndentStep returns the indentation of nested mappings in a document
+func yamlIndentStep(node *yaml.Node) int {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return defaultYAMLIndent
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if isBlockMapping(value) && len(value.Content) > 0 {
+			if step := value.Content[0].Column - key.Column; step > 0 {
+				return step
+			}
+		}
+	}
+	return defaultYAMLIndent
+}
+
+// isBlockMapping reports whether a node is a mapping in block style
+func isBlockMapping(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && node.Style&yaml.FlowStyle == 0
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation rules reported in ValidationError.Rule
+const (
+	RuleValidValues  = "valid_values"  // The value is not one of Field.ValidValues
+	RuleValidateTag  = "validate_tag"  // The value fails Field.ValidateTag
+	RuleValidateFunc = "validate_func" // The value fails Field.ValidateFunc
+	RuleType         = "type"          // The value cannot be converted to the field type
+)
+
+// ValidationError describes a value that failed a validation rule of a field
+type ValidationError struct {
+	Field   string // Name of the field
+	Value   any    // Offending value
+	Source  Source // Where the value came from, if known
+	Line    int    // Line of the value in the source file, if known
+	Column  int    // Column of the value in the source file, if known
+	Rule    string // Rule that failed (e.g. "validate_tag")
+	Detail  string // Rule detail (e.g. the validator tag that failed)
+	Message string // Human-readable description of the failure
+	Err     error  // Underlying error, if any
+}
+
+// Error formats the error as "[path:line:col: ]field: message[ (from source)]".
+// File sources are reported as a position prefix, other sources as a suffix.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.Source.Kind == SourceFile {
+		b.WriteString(e.Source.Name)
+		if e.Line > 0 {
+			fmt.Fprintf(&b, ":%d", e.Line)
+			if e.Column > 0 {
+				fmt.Fprintf(&b, ":%d", e.Column)
+			}
+		}
+		b.WriteString(": ")
+	}
+
+	fmt.Fprintf(&b, "%s: %s", e.Field, e.Message)
+
+	if e.Source.Kind != "" && e.Source.Kind != SourceFile {
+		fmt.Fprintf(&b, " (from %s)", e.Source)
+	}
+	return b.String()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors aggregates multiple validation failures.
+// It supports errors.As for both ValidationErrors and *ValidationError.
+type ValidationErrors []*ValidationError
+
+// Error returns one line per validation failure
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// OrNil returns nil if there are no failures, so that an empty collection is
+// not mistaken for an error.
+func (e ValidationErrors) OrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// validationError builds a ValidationError for a field value
+func validationError(f *Field, value any, rule, detail string, err error) *ValidationError {
+	return &ValidationError{
+		Field:   f.Name,
+		Value:   value,
+		Rule:    rule,
+		Detail:  detail,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+// validatorError describes a failure of the go-playground validator
+func validatorError(err error) (detail string, message error) {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) && len(verrs) > 0 {
+		tag := verrs[0].Tag()
+		if param := verrs[0].Param(); param != "" {
+			tag += "=" + param
+		}
+		return tag, fmt.Errorf("must satisfy %q", tag)
+	}
+	return "", err
+}
+
+// check validates a value with the field's validation rules and verifies that it
+// can be converted to the field type. Returns nil if the value is valid.
+func (f *Field) check(value any) *ValidationError {
+	if err := f.Validate(value); err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return verr
+		}
+		return validationError(f, value, RuleValidateFunc, "", err)
+	}
+
+	if _, err := readTyped(f, value); err != nil {
+		return validationError(f, value, RuleType, string(f.Type), fmt.Errorf("must be a valid %s", f.Type))
+	}
+	return nil
+}
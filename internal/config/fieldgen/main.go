@@ -0,0 +1,437 @@
+// Command fieldgen generates configuration field definitions from a YAML schema.
+//
+// For each schema file it emits the group constant, the Default* variables that
+// can be overridden at build time with -ldflags, the typed Field variables, the
+// init function registering them in Fields, and typed accessor functions.
+//
+// Usage (from a go:generate directive in the config package):
+//
+//	go run ./fieldgen -schema fields_app.yaml -out fields_app.go
+//
+// With -table, a Markdown table of the fields is printed to stdout instead,
+// for use in the README.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schema is the declarative definition of a group of configuration fields
+type schema struct {
+	Group  groupSpec   `yaml:"group"`
+	Fields []fieldSpec `yaml:"fields"`
+}
+
+// groupSpec defines the group constant shared by all fields of a schema
+type groupSpec struct {
+	Const string `yaml:"const"` // Name of the Go constant (e.g. "GroupApplication")
+	Name  string `yaml:"name"`  // Group name (e.g. "Application")
+	Doc   string `yaml:"doc"`   // Doc comment of the constant
+}
+
+// fieldSpec defines a single configuration field.
+// The attributes mirror config.Field.
+type fieldSpec struct {
+	Section      string  `yaml:"section"`       // Comment emitted before the field, starting a new section
+	Var          string  `yaml:"var"`           // Name of the Go variable (e.g. "FieldAppLogLevel")
+	Doc          string  `yaml:"doc"`           // Doc comment of the variable, without the variable name
+	Name         string  `yaml:"name"`          // Configuration key name
+	Type         string  `yaml:"type"`          // Field type: string, bool, int, float or duration
+	Default      *scalar `yaml:"default"`       // Default value; omit for no default
+	DefaultDoc   string  `yaml:"default_doc"`   // Comment of the Default* variable
+	Description  string  `yaml:"description"`   // Short description for CLI help
+	Docstring    string  `yaml:"docstring"`     // Detailed documentation
+	Hidden       bool    `yaml:"hidden"`        // Whether to hide from normal listing
+	Shorthand    string  `yaml:"shorthand"`     // Short flag name
+	ValidValues  []any   `yaml:"valid_values"`  // Allowed values
+	ValidateTag  string  `yaml:"validate_tag"`  // Go validator tag
+	ValidateFunc string  `yaml:"validate_func"` // Name of a custom validation function
+	Example      string  `yaml:"example"`       // Example value for documentation
+	Deprecated   string  `yaml:"deprecated"`    // Deprecation message
+	Accessor     string  `yaml:"accessor"`      // Name of the typed accessor; defaults to Var without "Field"
+}
+
+// scalar holds the text of a YAML scalar regardless of its tag,
+// so that defaults like `false` or `15m` are kept verbatim.
+type scalar struct {
+	Value string
+}
+
+func (s *scalar) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a scalar value", node.Line)
+	}
+	s.Value = node.Value
+	return nil
+}
+
+// fieldType describes how a schema type maps to Go code
+type fieldType struct {
+	Const      string                     // config.FieldType constant
+	GoType     string                     // Go type of the values
+	DefaultFn  string                     // Function converting the Default* string
+	ParseValue func(s string) error       // Validates a default value
+	Literal    func(v any) (string, bool) // Renders a valid value as a Go literal
+}
+
+var fieldTypes = map[string]fieldType{
+	"string": {
+		Const: "FieldTypeString", GoType: "string", DefaultFn: "defaultString",
+		ParseValue: func(string) error { return nil },
+		Literal: func(v any) (string, bool) {
+			s, ok := v.(string)
+			return strconv.Quote(s), ok
+		},
+	},
+	"bool": {
+		Const: "FieldTypeBool", GoType: "bool", DefaultFn: "defaultBool",
+		ParseValue: func(s string) error {
+			if s != "true" && s != "false" {
+				return fmt.Errorf("must be 'true' or 'false'")
+			}
+			return nil
+		},
+		Literal: func(v any) (string, bool) {
+			b, ok := v.(bool)
+			return strconv.FormatBool(b), ok
+		},
+	},
+	"int": {
+		Const: "FieldTypeInt", GoType: "int", DefaultFn: "defaultInt",
+		ParseValue: func(s string) error {
+			_, err := strconv.Atoi(s)
+			return err
+		},
+		Literal: func(v any) (string, bool) {
+			i, ok := v.(int)
+			return strconv.Itoa(i), ok
+		},
+	},
+	"float": {
+		Const: "FieldTypeFloat", GoType: "float64", DefaultFn: "defaultFloat",
+		ParseValue: func(s string) error {
+			_, err := strconv.ParseFloat(s, 64)
+			return err
+		},
+		Literal: func(v any) (string, bool) {
+			switch v := v.(type) {
+			case float64:
+				return fmt.Sprintf("float64(%v)", v), true
+			case int:
+				return fmt.Sprintf("float64(%d)", v), true
+			}
+			return "", false
+		},
+	},
+	"duration": {
+		Const: "FieldTypeDuration", GoType: "time.Duration", DefaultFn: "defaultDuration",
+		ParseValue: func(s string) error {
+			_, err := time.ParseDuration(s)
+			return err
+		},
+		Literal: func(v any) (string, bool) {
+			s, ok := v.(string)
+			if !ok {
+				return "", false
+			}
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return "", false
+			}
+			return fmt.Sprintf("time.Duration(%d)", int64(d)), true
+		},
+	},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("fieldgen: ")
+
+	schemaPath := flag.String("schema", "", "path of the YAML schema file")
+	outPath := flag.String("out", "", "path of the generated Go file")
+	table := flag.Bool("table", false, "print a Markdown table of the fields instead of generating code")
+	flag.Parse()
+
+	if *schemaPath == "" || (*outPath == "" && !*table) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	s, err := readSchema(*schemaPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *table {
+		fmt.Print(renderTable(s))
+		return
+	}
+
+	pkgPath, err := importPath(filepath.Dir(*outPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(s, filepath.Base(*schemaPath), pkgPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*outPath, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readSchema reads and validates a schema file
+func readSchema(path string) (*schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s schema
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// validate checks the schema for missing or inconsistent attributes
+func (s *schema) validate() error {
+	if s.Group.Const == "" || s.Group.Name == "" {
+		return fmt.Errorf("group: const and name are required")
+	}
+
+	vars := make(map[string]bool)
+	for i, f := range s.Fields {
+		if f.Var == "" || f.Name == "" {
+			return fmt.Errorf("fields[%d]: var and name are required", i)
+		}
+		if !strings.HasPrefix(f.Var, "Field") {
+			return fmt.Errorf("%s: var must start with \"Field\"", f.Var)
+		}
+		if vars[f.Var] {
+			return fmt.Errorf("%s: duplicate var", f.Var)
+		}
+		vars[f.Var] = true
+
+		ft, ok := fieldTypes[f.Type]
+		if !ok {
+			return fmt.Errorf("%s: unsupported type %q", f.Var, f.Type)
+		}
+		if f.Default != nil && f.Default.Value != "" {
+			if err := ft.ParseValue(f.Default.Value); err != nil {
+				return fmt.Errorf("%s: invalid default %q: %w", f.Var, f.Default.Value, err)
+			}
+		}
+		for _, v := range f.ValidValues {
+			if _, ok := ft.Literal(v); !ok {
+				return fmt.Errorf("%s: valid value %v is not a %s", f.Var, v, f.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultVar returns the name of the Default* variable of a field
+func (f *fieldSpec) defaultVar() string {
+	return "Default" + strings.TrimPrefix(f.Var, "Field")
+}
+
+// accessor returns the name of the typed accessor function of a field
+func (f *fieldSpec) accessor() string {
+	if f.Accessor != "" {
+		return f.Accessor
+	}
+	return strings.TrimPrefix(f.Var, "Field")
+}
+
+// generate renders the Go source for a schema
+func generate(s *schema, schemaName, pkgPath string) ([]byte, error) {
+	var b bytes.Buffer
+	p := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	p("// Code generated by fieldgen from %s. DO NOT EDIT.\n\n", schemaName)
+	p("package config\n\n")
+
+	for _, f := range s.Fields {
+		if f.Type == "duration" {
+			p("import \"time\"\n\n")
+			break
+		}
+	}
+
+	if s.Group.Doc != "" {
+		p("// %s\n", s.Group.Doc)
+	}
+	p("const %s = %q\n\n", s.Group.Const, s.Group.Name)
+
+	// Default values, overridable at build time
+	var defaults []fieldSpec
+	for _, f := range s.Fields {
+		if f.Default != nil {
+			defaults = append(defaults, f)
+		}
+	}
+	if len(defaults) > 0 {
+		first := defaults[0]
+		p("// Default values for %s configuration fields.\n", strings.ToLower(s.Group.Name))
+		p("// These are defined as string variables to allow build-time definition with -ldflags.\n")
+		p("// For example: go build -ldflags \"-X %s.%s=%s\"\n", pkgPath, first.defaultVar(), first.Default.Value)
+		p("var (\n")
+		for _, f := range defaults {
+			doc := f.DefaultDoc
+			if doc == "" {
+				doc = "Default value of " + f.Name
+			}
+			p("%s = %q // %s\n", f.defaultVar(), f.Default.Value, doc)
+		}
+		p(")\n\n")
+	}
+
+	// Registration
+	p("func init() {\n")
+	p("// Register all %s configuration fields\n", strings.ToLower(s.Group.Name))
+	p("Fields.Add(\n")
+	for _, f := range s.Fields {
+		p("%s.Field,\n", f.Var)
+	}
+	p(")\n}\n\n")
+
+	// Field definitions
+	for _, f := range s.Fields {
+		ft := fieldTypes[f.Type]
+		if f.Section != "" {
+			p("// %s\n\n", f.Section)
+		}
+		if f.Doc != "" {
+			p("// %s %s\n", f.Var, f.Doc)
+		}
+		p("var %s = NewTypedField[%s](&Field{\n", f.Var, ft.GoType)
+		p("Name: %q,\n", f.Name)
+		p("Group: %s,\n", s.Group.Const)
+		p("Type: %s,\n", ft.Const)
+		if f.Default != nil {
+			p("Default: %s(%s),\n", ft.DefaultFn, f.defaultVar())
+			p("DefaultVar: %q,\n", f.defaultVar())
+			p("DeclaredDefault: %s(%q),\n", ft.DefaultFn, f.Default.Value)
+		}
+		p("Description: %q,\n", f.Description)
+		if f.Docstring != "" {
+			p("Docstring: %s,\n", quote(strings.TrimRight(f.Docstring, "\n")))
+		}
+		if f.Hidden {
+			p("Hidden: true,\n")
+		}
+		if f.Shorthand != "" {
+			p("Shorthand: %q,\n", f.Shorthand)
+		}
+		if len(f.ValidValues) > 0 {
+			vals := make([]string, 0, len(f.ValidValues))
+			for _, v := range f.ValidValues {
+				lit, _ := ft.Literal(v)
+				vals = append(vals, lit)
+			}
+			p("ValidValues: []any{%s},\n", strings.Join(vals, ", "))
+		}
+		if f.ValidateTag != "" {
+			p("ValidateTag: %q,\n", f.ValidateTag)
+		}
+		if f.ValidateFunc != "" {
+			p("ValidateFunc: %s,\n", f.ValidateFunc)
+		}
+		if f.Example != "" {
+			p("Example: %q,\n", f.Example)
+		}
+		if f.Deprecated != "" {
+			p("Deprecated: %q,\n", f.Deprecated)
+		}
+		p("})\n\n")
+	}
+
+	// Typed accessors
+	p("// Typed accessors for %s configuration fields\n\n", strings.ToLower(s.Group.Name))
+	for _, f := range s.Fields {
+		ft := fieldTypes[f.Type]
+		p("// %s returns the resolved value of %s.\n", f.accessor(), f.Name)
+		p("func %s() %s {\nreturn %s.Value()\n}\n\n", f.accessor(), ft.GoType, f.Var)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// quote returns a Go string literal for s, preferring a raw string literal
+func quote(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// renderTable renders the fields of a schema as a Markdown table
+func renderTable(s *schema) string {
+	var b strings.Builder
+	b.WriteString("| Field | Type | Default | Description |\n")
+	b.WriteString("|-------|------|---------|-------------|\n")
+	for _, f := range s.Fields {
+		def := "`nil`"
+		if f.Default != nil && f.Default.Value != "" {
+			def = "`" + f.Default.Value + "`"
+		}
+		desc := f.Description
+		if f.Hidden {
+			desc += " (hidden)"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", f.Name, f.Type, def, desc)
+	}
+	return b.String()
+}
+
+// importPath determines the import path of the package in dir
+// by locating the enclosing go.mod file.
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for root := abs; ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			for line := range strings.SplitSeq(string(data), "\n") {
+				if mod, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					rel, err := filepath.Rel(root, abs)
+					if err != nil {
+						return "", err
+					}
+					return strings.TrimSpace(mod) + "/" + filepath.ToSlash(rel), nil
+				}
+			}
+			return "", fmt.Errorf("%s: module directive not found", filepath.Join(root, "go.mod"))
+		}
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("go.mod not found for %s", abs)
+		}
+	}
+}
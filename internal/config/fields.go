@@ -1,8 +1,13 @@
 package config
 
+// Field definitions are generated from the schema files. See fieldgen for the format.
+//go:generate go run ./fieldgen -schema fields_app.yaml -out fields_app.go
+//go:generate go run ./fieldgen -schema fields_network.yaml -out fields_network.go
+
 import (
 	"fmt"
 	"iter"
+	"log"
 	"slices"
 	"strings"
 
@@ -24,23 +29,25 @@ const (
 // This struct serves as the single source of truth for configuration parameters,
 // containing everything needed to define, validate, and document a config field.
 type Field struct {
-	Name         string          // The configuration key name (e.g., "log.level")
-	Group        string          // Logical grouping for organization (e.g., "Application")
-	Type         FieldType       // Data type of the field
-	Default      any             // Default value if not specified
-	Description  string          // Short description for CLI help
-	Docstring    string          // Detailed documentation for pager output
-	Hidden       bool            // Whether to hide from normal listing
-	Shorthand    string          // Short flag name (e.g., "v" for verbose)
-	ValidValues  []any           // Allowed values for validation
-	ValidateTag  string          // Go validator tag for validation
-	ValidateFunc func(any) error // Custom validation function
-	Example      string          // Example value for documentation
-	Deprecated   string          // Deprecation message if field is deprecated
+	Name            string          // The configuration key name (e.g., "log.level")
+	Group           string          // Logical grouping for organization (e.g., "Application")
+	Type            FieldType       // Data type of the field
+	Default         any             // Default value if not specified
+	DefaultVar      string          // Build-time variable backing Default (set with -ldflags -X)
+	DeclaredDefault any             // Default as declared in the source code, before build-time overrides
+	Description     string          // Short description for CLI help
+	Docstring       string          // Detailed documentation for pager output
+	Hidden          bool            // Whether to hide from normal listing
+	Shorthand       string          // Short flag name (e.g., "v" for verbose)
+	ValidValues     []any           // Allowed values for validation
+	ValidateTag     string          // Go validator tag for validation
+	ValidateFunc    func(any) error // Custom validation function
+	Example         string          // Example value for documentation
+	Deprecated      string          // Deprecation message if field is deprecated
 }
 
 // Validate performs validation on a field value using the configured validation rules.
-// Returns nil if validation passes, or an error describing the validation failure.
+// Returns nil if validation passes, or a *ValidationError describing the failure.
 func (f *Field) Validate(value any) error {
 	if value == nil {
 		return nil
@@ -51,26 +58,46 @@ func (f *Field) Validate(value any) error {
 		if slices.Contains(f.ValidValues, value) {
 			return nil
 		}
-		return fmt.Errorf("%s: valid values: %v", f.Name, f.ValidValues)
+		return validationError(f, value, RuleValidValues, "", fmt.Errorf("valid values: %v", f.ValidValues))
 	}
 
 	// Apply validator tag if specified
 	if f.ValidateTag != "" {
 		if err := validator.New().Var(value, f.ValidateTag); err != nil {
-			return fmt.Errorf("%s: %w", f.Name, err)
+			detail, msg := validatorError(err)
+			verr := validationError(f, value, RuleValidateTag, detail, msg)
+			verr.Err = err
+			return verr
 		}
 	}
 
 	// Apply custom validation function if specified
 	if f.ValidateFunc != nil {
 		if err := f.ValidateFunc(value); err != nil {
-			return fmt.Errorf("%s: %w", f.Name, err)
+			return validationError(f, value, RuleValidateFunc, "", err)
 		}
 	}
 
 	return nil
 }
 
+// DefaultOverridden reports whether Default was overridden at build time,
+// i.e. DefaultVar was set with -ldflags to a value other than the declared default.
+func (f *Field) DefaultOverridden() bool {
+	return f.DefaultVar != "" && f.Default != f.DeclaredDefault
+}
+
+// checkDefault verifies that the default value matches the field type.
+func (f *Field) checkDefault() error {
+	if f.Default == nil {
+		return nil
+	}
+	if t := fieldTypeOfValue(f.Default); t != f.Type {
+		return fmt.Errorf("default value %v (%T) does not match type %s", f.Default, f.Default, f.Type)
+	}
+	return nil
+}
+
 // FieldCollection represents a collection of configuration fields
 type FieldCollection []*Field
 
@@ -83,9 +110,13 @@ type FieldGroup map[string]FieldCollection
 // Fields is the global collection of all configuration fields
 var Fields = FieldCollection{}
 
-// Add adds fields to the collection, replacing existing fields with the same name
+// Add adds fields to the collection, replacing existing fields with the same name.
+// Panics if a field's default value does not match its type.
 func (fc *FieldCollection) Add(fields ...*Field) {
 	for _, field := range fields {
+		if err := field.checkDefault(); err != nil {
+			log.Panicf("Field %s: %v", field.Name, err)
+		}
 		// Check if field already exists and replace it
 		for i, existingField := range *fc {
 			if existingField.Name == field.Name {
@@ -141,24 +172,40 @@ func (fc *FieldCollection) Map() FieldMap {
 	return fields
 }
 
-// Global flag fields that are available to all commands
+// Global flag fields that are available to all commands.
+// They are not part of Fields, since they are not persisted settings.
+
+// flagFields is the collection of global flag fields
+var flagFields = FieldCollection{
+	FieldFlagConfig.Field,
+	FieldFlagVerbose.Field,
+	FieldFlagStrict.Field,
+}
 
 // FieldFlagConfig defines the config file flag
-var FieldFlagConfig = &Field{
+var FieldFlagConfig = NewTypedField[string](&Field{
 	Name:         "config",
-	Type:         "string",
+	Type:         FieldTypeString,
 	Shorthand:    "c",
-	Description:  "Config file path",
-	Docstring:    `The configuration file should of one of the extensions: yaml, yml, json, toml, hcl, env`,
+	Description:  "User config file path (repeatable, merged left to right)",
+	Docstring:    `The configuration files of the user scope, merged over the system scope and under the project scope. Several files are merged left to right: they can be given by repeating the flag, or as a path list in the environment (e.g. CONFAPP_CONFIG=base.yaml:prod.yaml). Each file should of one of the extensions: yaml, yml, json, toml, hcl, env`,
 	ValidateTag:  "filepath",
 	ValidateFunc: validateConfigFile,
-}
+})
 
 // FieldFlagVerbose defines the verbose flag
-var FieldFlagVerbose = &Field{
+var FieldFlagVerbose = NewTypedField[bool](&Field{
 	Name:        "verbose",
-	Type:        "bool",
+	Type:        FieldTypeBool,
 	Shorthand:   "v",
 	Description: "Display more verbose output in console output.",
 	Docstring:   "",
-}
+})
+
+// FieldFlagStrict defines the strict mode flag
+var FieldFlagStrict = NewTypedField[bool](&Field{
+	Name:        "strict",
+	Type:        FieldTypeBool,
+	Description: "Fail if any configuration value is invalid.",
+	Docstring:   "In strict mode, every configuration value is validated at startup, whatever its source.",
+})
@@ -1,11 +1,15 @@
+// Code generated by fieldgen from fields_app.yaml. DO NOT EDIT.
+
 package config
 
+import "time"
+
 // GroupApplication is the logical group name for application-related configuration fields
 const GroupApplication = "Application"
 
 // Default values for application configuration fields.
 // These are defined as string variables to allow build-time definition with -ldflags.
-// For example: go build -ldflags "-X github.com/lucasdecamargo/go-appconfig-example/internal/config.DefaultAppEnvironment=prod"
+// For example: go build -ldflags "-X github.com/lucasdecamargo/go-appconfig-example/internal/config.DefaultAppEnvironment=dev"
 var (
 	DefaultAppEnvironment    = "dev"   // Default application environment
 	DefaultAppLogLevel       = "info"  // Default logging level
@@ -19,88 +23,139 @@ var (
 func init() {
 	// Register all application configuration fields
 	Fields.Add(
-		FieldAppEnvironment,
-		FieldAppLogLevel,
-		FieldAppLogOutput,
-		FieldAppLogFormat,
-		FieldAppUpdateUnstable,
-		FieldAppUpdateAuto,
-		FieldAppUpdatePeriod,
+		FieldAppEnvironment.Field,
+		FieldAppLogLevel.Field,
+		FieldAppLogOutput.Field,
+		FieldAppLogFormat.Field,
+		FieldAppUpdateUnstable.Field,
+		FieldAppUpdateAuto.Field,
+		FieldAppUpdatePeriod.Field,
 	)
 }
 
 // FieldAppEnvironment defines the application environment setting
-var FieldAppEnvironment = &Field{
-	Name:        "environment",
-	Group:       GroupApplication,
-	Type:        FieldTypeString,
-	Default:     defaultString(DefaultAppEnvironment),
-	Description: "The environment in which the application runs.",
-	Example:     "prod, test, dev",
-	Hidden:      true, // This field is not shown in the config list
-}
+var FieldAppEnvironment = NewTypedField[string](&Field{
+	Name:            "environment",
+	Group:           GroupApplication,
+	Type:            FieldTypeString,
+	Default:         defaultString(DefaultAppEnvironment),
+	DefaultVar:      "DefaultAppEnvironment",
+	DeclaredDefault: defaultString("dev"),
+	Description:     "The environment in which the application runs.",
+	Hidden:          true,
+	Example:         "prod, test, dev",
+})
 
 // Logging configuration fields
 
 // FieldAppLogLevel defines the application logging level
-var FieldAppLogLevel = &Field{
-	Name:        "log.level",
-	Group:       GroupApplication,
-	Type:        FieldTypeString,
-	Default:     defaultString(DefaultAppLogLevel),
-	Description: "The log level to use for the application.",
-	ValidValues: []any{"debug", "info", "warn", "error"},
-}
+var FieldAppLogLevel = NewTypedField[string](&Field{
+	Name:            "log.level",
+	Group:           GroupApplication,
+	Type:            FieldTypeString,
+	Default:         defaultString(DefaultAppLogLevel),
+	DefaultVar:      "DefaultAppLogLevel",
+	DeclaredDefault: defaultString("info"),
+	Description:     "The log level to use for the application.",
+	ValidValues:     []any{"debug", "info", "warn", "error"},
+})
 
 // FieldAppLogOutput defines the log output destination
-var FieldAppLogOutput = &Field{
-	Name:        "log.output",
-	Group:       GroupApplication,
-	Type:        FieldTypeString,
-	Default:     defaultString(DefaultAppLogOutput),
-	Description: "The output file to use for the application logs, if set.",
-	ValidateTag: "filepath",
-	Example:     "/var/log/app.log",
-}
+var FieldAppLogOutput = NewTypedField[string](&Field{
+	Name:            "log.output",
+	Group:           GroupApplication,
+	Type:            FieldTypeString,
+	Default:         defaultString(DefaultAppLogOutput),
+	DefaultVar:      "DefaultAppLogOutput",
+	DeclaredDefault: defaultString(""),
+	Description:     "The output file to use for the application logs, if set.",
+	ValidateTag:     "filepath",
+	Example:         "/var/log/app.log",
+})
 
 // FieldAppLogFormat defines the log output format
-var FieldAppLogFormat = &Field{
-	Name:        "log.format",
-	Group:       GroupApplication,
-	Type:        FieldTypeString,
-	Default:     defaultString(DefaultAppLogFormat),
-	Description: "The format to use for the application log file, if set.",
-	ValidValues: []any{"json", "text"},
-}
+var FieldAppLogFormat = NewTypedField[string](&Field{
+	Name:            "log.format",
+	Group:           GroupApplication,
+	Type:            FieldTypeString,
+	Default:         defaultString(DefaultAppLogFormat),
+	DefaultVar:      "DefaultAppLogFormat",
+	DeclaredDefault: defaultString("text"),
+	Description:     "The format to use for the application log file, if set.",
+	ValidValues:     []any{"json", "text"},
+})
 
 // Update configuration fields
 
 // FieldAppUpdateUnstable controls whether to receive unstable version updates
-var FieldAppUpdateUnstable = &Field{
-	Name:        "update.unstable",
-	Group:       GroupApplication,
-	Type:        FieldTypeBool,
-	Default:     defaultBool(DefaultAppUpdateUnstable),
-	Description: "Receive updates for unstable versions.",
-}
+var FieldAppUpdateUnstable = NewTypedField[bool](&Field{
+	Name:            "update.unstable",
+	Group:           GroupApplication,
+	Type:            FieldTypeBool,
+	Default:         defaultBool(DefaultAppUpdateUnstable),
+	DefaultVar:      "DefaultAppUpdateUnstable",
+	DeclaredDefault: defaultBool("false"),
+	Description:     "Receive updates for unstable versions.",
+})
 
 // FieldAppUpdateAuto controls automatic application updates
-var FieldAppUpdateAuto = &Field{
-	Name:        "update.auto",
-	Group:       GroupApplication,
-	Type:        FieldTypeBool,
-	Default:     defaultBool(DefaultAppUpdateAuto),
-	Description: "Automatically update the application when a new version is available.",
-}
+var FieldAppUpdateAuto = NewTypedField[bool](&Field{
+	Name:            "update.auto",
+	Group:           GroupApplication,
+	Type:            FieldTypeBool,
+	Default:         defaultBool(DefaultAppUpdateAuto),
+	DefaultVar:      "DefaultAppUpdateAuto",
+	DeclaredDefault: defaultBool("false"),
+	Description:     "Automatically update the application when a new version is available.",
+})
 
 // FieldAppUpdatePeriod defines how often to check for updates
-var FieldAppUpdatePeriod = &Field{
-	Name:         "update.period",
-	Group:        GroupApplication,
-	Type:         FieldTypeDuration,
-	Default:      defaultDuration(DefaultAppUpdatePeriod),
-	Description:  "The period to check for updates, if enabled.",
-	Docstring:    `The period can be a number of seconds, or a valid duration string.`,
-	ValidateFunc: validateDuration,
-	Example:      "1h, 15m, 10 (seconds)",
+var FieldAppUpdatePeriod = NewTypedField[time.Duration](&Field{
+	Name:            "update.period",
+	Group:           GroupApplication,
+	Type:            FieldTypeDuration,
+	Default:         defaultDuration(DefaultAppUpdatePeriod),
+	DefaultVar:      "DefaultAppUpdatePeriod",
+	DeclaredDefault: defaultDuration("15m"),
+	Description:     "The period to check for updates, if enabled.",
+	Docstring:       `The period can be a number of seconds, or a valid duration string.`,
+	ValidateFunc:    validateDuration,
+	Example:         "1h, 15m, 10 (seconds)",
+})
+
+// Typed accessors for application configuration fields
+
+// AppEnvironment returns the resolved value of environment.
+func AppEnvironment() string {
+	return FieldAppEnvironment.Value()
+}
+
+// AppLogLevel returns the resolved value of log.level.
+func AppLogLevel() string {
+	return FieldAppLogLevel.Value()
+}
+
+// AppLogOutput returns the resolved value of log.output.
+func AppLogOutput() string {
+	return FieldAppLogOutput.Value()
+}
+
+// AppLogFormat returns the resolved value of log.format.
+func AppLogFormat() string {
+	return FieldAppLogFormat.Value()
+}
+
+// AppUpdateUnstable returns the resolved value of update.unstable.
+func AppUpdateUnstable() bool {
+	return FieldAppUpdateUnstable.Value()
+}
+
+// AppUpdateAuto returns the resolved value of update.auto.
+func AppUpdateAuto() bool {
+	return FieldAppUpdateAuto.Value()
+}
+
+// AppUpdatePeriod returns the resolved value of update.period.
+func AppUpdatePeriod() time.Duration {
+	return FieldAppUpdatePeriod.Value()
 }
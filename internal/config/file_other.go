@@ -0,0 +1,27 @@
+//go:build !unix && !windows
+
+package config
+
+import (
+	"io/fs"
+	"os"
+)
+
+// lockFile is a no-op on platforms without file locks. Saves are still
+// serialized within the process.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without file locks.
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// chownLike is a no-op on platforms without file ownership.
+func chownLike(file string, info fs.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op on platforms where directories cannot be flushed.
+func syncDir(dir string) {}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package config
+
+import (
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on an open file, blocking until it is available
+func lockFile(f *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &overlapped)
+}
+
+// unlockFile releases a lock taken with lockFile
+func unlockFile(f *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &overlapped)
+}
+
+// chownLike is a no-op on Windows, where a replaced file takes the ownership
+// and inherited permissions of the new file.
+func chownLike(file string, info fs.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op on Windows, where directories cannot be flushed.
+func syncDir(dir string) {}
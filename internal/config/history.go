@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// historyLimit is the number of previous versions kept for each config file
+const historyLimit = 20
+
+// HistoryEntry is a previous version of a config file, recorded when the
+// file was changed.
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`    // Time of the change
+	Path    string    `json:"path"`    // Absolute path of the config file
+	Command []string  `json:"command"` // Command line that made the change
+	Keys    []string  `json:"keys"`    // Keys changed from this version
+	Existed bool      `json:"existed"` // Whether the file existed before the change
+	Content string    `json:"content"` // Content of the file before the change
+}
+
+// History returns the previous versions of a config file, most recent first.
+// Versions are kept under the state directory (see StateDir), up to the last
+// 20 changes of each file.
+func History(cfgFile string) ([]HistoryEntry, error) {
+	dir, err := historyDir(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read config history: %w", err)
+	}
+
+	var entries []HistoryEntry
+	for _, name := range slices.Backward(historyFiles(dirEntries)) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config history: %w", err)
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("invalid config history entry %s: %w", name, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// RecordHistory records the previous content of a config file changed
+// outside of Save, e.g. in an editor. Nothing is recorded if the content did
+// not change.
+func RecordHistory(cfgFile string, before []byte) error {
+	after, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	return recordHistory(cfgFile, before, true, after)
+}
+
+// Rollback restores the n-th previous version of a config file, 1 being the
+// version before the last change. The rollback is itself recorded in the
+// history, so that it can be undone. Returns the restored version.
+func Rollback(cfgFile string, n int) (*HistoryEntry, error) {
+	unlock, err := lockConfigFile(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := History(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(entries) {
+		return nil, fmt.Errorf("%s has %d previous versions, cannot restore version %d", cfgFile, len(entries), n)
+	}
+	entry := &entries[n-1]
+
+	before, err := os.ReadFile(cfgFile)
+	existed := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if entry.Existed {
+		err = writeFileAtomic(cfgFile, []byte(entry.Content), 0644)
+	} else if existed {
+		// The file was created by the change
+		err = os.Remove(cfgFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore config file %s: %w", cfgFile, err)
+	}
+
+	if err := recordHistory(cfgFile, before, existed, []byte(entry.Content)); err != nil {
+		warn(fmt.Errorf("failed to record config history: %w", err))
+	}
+	return entry, nil
+}
+
+// recordHistory records the content of a config file before a change, along
+// with the command line and the keys that changed. Entries beyond
+// historyLimit are removed, oldest first.
+func recordHistory(cfgFile string, before []byte, existed bool, after []byte) error {
+	if existed && bytes.Equal(before, after) {
+		return nil
+	}
+
+	entry := HistoryEntry{
+		Time:    time.Now(),
+		Path:    canonicalPath(cfgFile),
+		Command: commandLine(),
+		Keys:    changedKeys(configFileType(cfgFile), before, after),
+		Existed: existed,
+		Content: string(before),
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The history may hold credentials, like the config file
+	dir, err := historyDir(cfgFile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := entry.Time.UTC().Format("20060102T150405.000000000") + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return err
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	files := historyFiles(dirEntries)
+	for _, name := range files[:max(len(files)-historyLimit, 0)] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyFiles returns the names of the history entry files, oldest first
+func historyFiles(dirEntries []fs.DirEntry) []string {
+	var names []string
+	for _, e := range dirEntries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	// Names are timestamps, which sort chronologically
+	slices.Sort(names)
+	return names
+}
+
+// errNoStateDir is returned when the history cannot be kept because there is
+// no state directory (see StateDir), e.g. without a home directory
+var errNoStateDir = errors.New("no state directory to keep the config history in, set $XDG_STATE_HOME")
+
+// historyDir returns the directory of the history of a config file.
+// Each file has its own directory, named after a hash of its path.
+func historyDir(cfgFile string) (string, error) {
+	state := StateDir()
+	if state == "" {
+		return "", errNoStateDir
+	}
+	return filepath.Join(state, "history", pathKey(cfgFile)), nil
+}
+
+// commandLine returns the command line of the process, with the program name
+// stripped of its directory
+func commandLine() []string {
+	args := slices.Clone(os.Args)
+	if len(args) > 0 {
+		args[0] = filepath.Base(args[0])
+	}
+	return args
+}
+
+// changedKeys returns the keys whose values differ between two versions of
+// a config file. Versions that cannot be decoded are considered empty.
+func changedKeys(format string, before, after []byte) []string {
+	decode := func(data []byte) map[string]string {
+		settings, err := decodeSettings(format, data)
+		if err != nil {
+			return nil
+		}
+		return flattenSettings(settings, "")
+	}
+	old, cur := decode(before), decode(after)
+
+	var keys []string
+	for key, val := range old {
+		if v, ok := cur[key]; !ok || v != val {
+			keys = append(keys, key)
+		}
+	}
+	for key := range cur {
+		if _, ok := old[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestHistoryWithoutStateDir checks that no history is written relative to the
+// working directory when there is no state directory
+func TestHistoryWithoutStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "")
+	if dir := StateDir(); dir != "" {
+		t.Skipf("state directory %s found without $HOME", dir)
+	}
+	t.Chdir(t.TempDir())
+
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := recordHistory(cfgFile, nil, false, []byte("log:\n  level: warn\n"))
+	if !errors.Is(err, errNoStateDir) {
+		t.Errorf("recordHistory() = %v, want %v", err, errNoStateDir)
+	}
+	if _, err := History(cfgFile); !errors.Is(err, errNoStateDir) {
+		t.Errorf("History() = %v, want %v", err, errNoStateDir)
+	}
+	if _, err := os.Stat("history"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("history written to the working directory: %v", err)
+	}
+}
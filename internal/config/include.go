@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// IncludeKey is the config file key listing other config files to include.
+// Its value is a path or glob pattern, or a list of them, relative to the
+// directory of the including file.
+const IncludeKey = "include"
+
+// fragmentExts lists the extensions of the fragments read from drop-in directories
+var fragmentExts = []string{"yaml", "yml", "toml", "json"}
+
+// DropInDir returns the drop-in directory of a config file: its path with the
+// extension replaced by ".d" (e.g. config.d for config.yaml).
+func DropInDir(cfgFile string) string {
+	return strings.TrimSuffix(cfgFile, filepath.Ext(cfgFile)) + ".d"
+}
+
+// readConfigLayers reads a configuration file along with the files it
+// includes and the fragments of its drop-in directory. Included files are
+// merged before the including file, so that its own settings take precedence,
+// and drop-in fragments are merged after the file, in lexical order.
+// Fragments are read even if the file does not exist, so that packages can
+// ship them without creating the main file.
+func readConfigLayers(file configFile) ([]*fileLayer, error) {
+	layer, err := readConfigFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []*fileLayer
+	if layer != nil {
+		if layers, err = expandIncludes(layer, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	fragments, err := dropInFragments(DropInDir(file.Path))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range fragments {
+		fragment, err := readConfigFile(configFile{Path: path, Scope: file.Scope})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if fragment == nil {
+			continue
+		}
+		fragment.IncludedBy = file.Path
+
+		expanded, err := expandIncludes(fragment, nil)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, expanded...)
+	}
+
+	return layers, nil
+}
+
+// expandIncludes returns the layers of the files included by a layer,
+// recursively, followed by the layer itself without the include key.
+// chain holds the files including the layer, to detect include cycles.
+func expandIncludes(layer *fileLayer, chain []string) ([]*fileLayer, error) {
+	abs, err := filepath.Abs(layer.Path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(chain, abs), " -> "))
+	}
+	chain = slices.Concat(chain, []string{abs})
+
+	patterns, err := includePatterns(layer.Settings[IncludeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", layer.Path, err)
+	}
+	delete(layer.Settings, IncludeKey)
+
+	var layers []*fileLayer
+	for _, pattern := range patterns {
+		paths, err := globInclude(filepath.Dir(layer.Path), pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Path, err)
+		}
+		for _, path := range paths {
+			included, err := readConfigFile(configFile{Path: path, Scope: layer.Scope})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if included == nil {
+				continue
+			}
+			included.IncludedBy = layer.Path
+
+			expanded, err := expandIncludes(included, chain)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, expanded...)
+		}
+	}
+
+	return append(layers, layer), nil
+}
+
+// includePatterns returns the patterns of an include value
+func includePatterns(value any) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []any:
+		patterns := make([]string, len(value))
+		for i, v := range value {
+			pattern, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: invalid pattern %v", IncludeKey, v)
+			}
+			patterns[i] = pattern
+		}
+		return patterns, nil
+	default:
+		return nil, fmt.Errorf("%s must be a path or a list of paths", IncludeKey)
+	}
+}
+
+// globInclude returns the files matching an include pattern, in lexical order.
+// Relative patterns are resolved from dir. A path that is not a pattern must
+// exist, while a pattern may match no file.
+func globInclude(dir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid pattern %s: %w", IncludeKey, pattern, err)
+	}
+	if len(paths) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, fmt.Errorf("%s: %s: %w", IncludeKey, pattern, fs.ErrNotExist)
+	}
+	return paths, nil
+}
+
+// dropInFragments returns the config fragments of a drop-in directory, in
+// lexical order. Returns nil if the directory does not exist.
+func dropInFragments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read drop-in directory: %w", err)
+	}
+
+	var fragments []string
+	for _, entry := range entries {
+		ext := strings.TrimPrefix(filepath.Ext(entry.Name()), ".")
+		if entry.Type().IsRegular() && slices.Contains(fragmentExts, ext) {
+			fragments = append(fragments, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return fragments, nil
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the generated schema.
+// Draft 7 is the best supported one among editors (e.g. yaml-language-server).
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern matches duration strings like "1h30m" or numbers of seconds
+const durationPattern = `^(([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+|[0-9]+(\.[0-9]+)?)$`
+
+// durationRegexp matches the duration strings of durationPattern
+var durationRegexp = regexp.MustCompile(durationPattern)
+
+// JSONSchema is a JSON Schema document or subschema
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 any                    `json:"type,omitempty"` // A type name or a list of type names
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Examples             []any                  `json:"examples,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+}
+
+// validateTagFormats maps validator tags to JSON Schema formats.
+// Tags without a standard format are emitted as-is, as an annotation.
+var validateTagFormats = map[string]string{
+	"url":      "uri",
+	"uri":      "uri",
+	"http_url": "uri",
+	"email":    "email",
+	"hostname": "hostname",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"uuid":     "uuid",
+	"datetime": "date-time",
+	"filepath": "filepath",
+	"file":     "filepath",
+	"dirpath":  "dirpath",
+	"dir":      "dirpath",
+}
+
+// GenerateJSONSchema translates the fields of a collection into a JSON Schema
+// document describing a configuration file. Dotted field names become nested
+// objects, and unknown keys are rejected.
+func GenerateJSONSchema(fields FieldCollection) *JSONSchema {
+	root := newObjectSchema()
+	root.Schema = JSONSchemaDraft
+	root.Title = consts.AppName + " configuration"
+
+	for _, groupFields := range fields.GroupIter() {
+		for _, field := range groupFields {
+			parent := root
+			parts := strings.Split(field.Name, ".")
+			for _, part := range parts[:len(parts)-1] {
+				child, ok := parent.Properties[part]
+				if !ok {
+					child = newObjectSchema()
+					parent.Properties[part] = child
+				}
+				parent = child
+			}
+			parent.Properties[parts[len(parts)-1]] = fieldSchema(field)
+		}
+	}
+
+	root.Properties[IncludeKey] = &JSONSchema{
+		Title:       IncludeKey,
+		Description: "Config files to include, as a path or glob pattern or a list of them, relative to this file",
+		Type:        []string{"string", "array"},
+		Items:       &JSONSchema{Type: "string"},
+	}
+
+	return root
+}
+
+// newObjectSchema returns a schema for an object that rejects unknown properties
+func newObjectSchema() *JSONSchema {
+	additional := false
+	return &JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: &additional,
+	}
+}
+
+// fieldSchema translates a single field into a JSON Schema
+func fieldSchema(f *Field) *JSONSchema {
+	s := &JSONSchema{
+		Title:      f.Name,
+		Deprecated: f.Deprecated != "",
+	}
+
+	switch f.Type {
+	case FieldTypeString:
+		s.Type = "string"
+	case FieldTypeBool:
+		s.Type = "boolean"
+	case FieldTypeInt:
+		s.Type = "integer"
+	case FieldTypeFloat:
+		s.Type = "number"
+	case FieldTypeDuration:
+		// Durations are strings, or numbers of seconds
+		s.Type = []string{"string", "number"}
+		s.Pattern = durationPattern
+	}
+
+	// Description, documentation and deprecation notice
+	var desc []string
+	if f.Description != "" {
+		desc = append(desc, f.Description)
+	}
+	if f.Docstring != "" {
+		desc = append(desc, f.Docstring)
+	}
+	if f.Deprecated != "" {
+		desc = append(desc, "Deprecated: "+f.Deprecated)
+	}
+	s.Description = strings.Join(desc, "\n\n")
+
+	if f.Default != nil {
+		s.Default = f.Default
+		if f.Type == FieldTypeDuration {
+			s.Default = f.FormatValue(f.Default)
+		}
+	}
+
+	if len(f.ValidValues) > 0 {
+		s.Enum = f.ValidValues
+	}
+
+	s.Examples = schemaExamples(f)
+
+	for tag := range strings.SplitSeq(f.ValidateTag, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		if format, ok := validateTagFormats[name]; ok {
+			s.Format = format
+			break
+		}
+	}
+
+	return s
+}
+
+// schemaExamples returns the examples of a field for its schema. Example may
+// list several values separated by commas (e.g. "1h, 15m"), each of which
+// becomes an example of the field type. Values that are not valid instances of
+// the schema, such as annotated ones (e.g. "10 (seconds)"), are left out.
+func schemaExamples(f *Field) []any {
+	var examples []any
+	for example := range strings.SplitSeq(f.Example, ",") {
+		example = strings.TrimSpace(example)
+		if example == "" {
+			continue
+		}
+
+		var value any = example
+		if f.Type == FieldTypeDuration {
+			// Durations are written as strings in config files
+			if !durationRegexp.MatchString(example) {
+				continue
+			}
+		} else {
+			typed, err := readTyped(f, example)
+			if err != nil {
+				continue
+			}
+			value = typed
+		}
+		if len(f.ValidValues) > 0 && !slices.Contains(f.ValidValues, value) {
+			continue
+		}
+		examples = append(examples, value)
+	}
+	return examples
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSchemaExamples(t *testing.T) {
+	tests := []struct {
+		name  string
+		field *Field
+		want  []any
+	}{
+		{
+			name:  "single",
+			field: &Field{Type: FieldTypeString, Example: "/var/log/app.log"},
+			want:  []any{"/var/log/app.log"},
+		},
+		{
+			name:  "list",
+			field: &Field{Type: FieldTypeString, Example: "prod, test, dev"},
+			want:  []any{"prod", "test", "dev"},
+		},
+		{
+			name:  "duration",
+			field: &Field{Type: FieldTypeDuration, Example: "1h, 15m, 10 (seconds), 90"},
+			want:  []any{"1h", "15m", "90"},
+		},
+		{
+			name:  "typed",
+			field: &Field{Type: FieldTypeInt, Example: "8080, port"},
+			want:  []any{8080},
+		},
+		{
+			name:  "valid values",
+			field: &Field{Type: FieldTypeString, Example: "debug, verbose", ValidValues: []any{"debug", "info"}},
+			want:  []any{"debug"},
+		},
+		{
+			name:  "none",
+			field: &Field{Type: FieldTypeBool},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaExamples(tt.field); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Every example of the declared fields is kept
+	for _, f := range Fields {
+		if f.Example != "" && len(schemaExamples(f)) == 0 {
+			t.Errorf("%s: no valid example in %q", f.Name, f.Example)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+)
+
+// Scope identifies a configuration file layer.
+// Files of narrower scopes take precedence: project over user over system.
+type Scope string
+
+const (
+	ScopeSystem  Scope = "system"  // System-wide configuration in each system config directory
+	ScopeUser    Scope = "user"    // Per-user configuration, selected with the config flag
+	ScopeProject Scope = "project" // Per-project configuration found from the working directory
+)
+
+// Scopes lists the configuration scopes in the order they are merged
+// (lowest precedence first).
+var Scopes = []Scope{ScopeSystem, ScopeUser, ScopeProject}
+
+// ParseScope returns the scope with the given name
+func ParseScope(name string) (Scope, error) {
+	if scope := Scope(name); slices.Contains(Scopes, scope) {
+		return scope, nil
+	}
+	return "", fmt.Errorf("invalid scope: %s (valid scopes: %v)", name, Scopes)
+}
+
+// ScopeFile returns the path of the configuration file of a scope, which is
+// the file written for the scope. The system file is the one in the first
+// system config directory (see systemConfigDirs). The user file is set with
+// the config flag field (FieldFlagConfig), and is empty unless exactly one
+// file is specified (see UserConfigFiles). The project file is the one found by
+// walking up from the working directory (see findProjectFile), or
+// .confapp.yaml in the working directory if there is none.
+func ScopeFile(scope Scope) string {
+	switch scope {
+	case ScopeSystem:
+		return filepath.Join(systemConfigDirs()[0], consts.AppName, "config.yaml")
+	case ScopeUser:
+		if files := UserConfigFiles(); len(files) == 1 {
+			return files[0]
+		}
+		return ""
+	case ScopeProject:
+		if path, _ := findProjectFile(); path != "" {
+			return path
+		}
+		dir, _ := os.Getwd()
+		return filepath.Join(dir, projectFileName("yaml"))
+	default:
+		return ""
+	}
+}
+
+// UserConfigFiles returns the user config files, in the order they are merged.
+// The config flag field (FieldFlagConfig) holds them as a path list, with paths
+// separated by os.PathListSeparator.
+func UserConfigFiles() []string {
+	var files []string
+	for _, path := range filepath.SplitList(FieldFlagConfig.Value()) {
+		if path = strings.TrimSpace(path); path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// scopePaths returns the configuration files read for a scope, by increasing
+// precedence. The file in the first system config directory wins, and user
+// files are merged left to right.
+func scopePaths(scope Scope) []string {
+	switch scope {
+	case ScopeSystem:
+		paths := systemConfigFiles()
+		slices.Reverse(paths)
+		return paths
+	case ScopeUser:
+		return UserConfigFiles()
+	default:
+		if path := ScopeFile(scope); path != "" {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+// ConfigPath describes a configuration file considered when loading the configuration
+type ConfigPath struct {
+	Path       string // Path of the file
+	Scope      Scope  // Scope of the file
+	Loaded     bool   // Whether the file exists and was merged into the configuration
+	IncludedBy string // File including this one, or whose drop-in directory holds it
+}
+
+// ConfigPaths returns every configuration file that is considered, in the
+// order they are looked up: the system files by decreasing precedence, the
+// user files, then the project files checked in each directory from the
+// working directory up to the root, until one is found. Each file is followed
+// by the files it includes and its drop-in fragments, in merge order; drop-in
+// fragments are loaded even if the file itself does not exist.
+func ConfigPaths() []ConfigPath {
+	mu.RLock()
+	byPath := make(map[string]*fileLayer, len(fileLayers))
+	for _, layer := range fileLayers {
+		byPath[layer.Path] = layer
+	}
+	// Group included files and fragments under the candidate file they come
+	// from, which may not exist for drop-in fragments
+	included := make(map[string][]*fileLayer)
+	for _, layer := range fileLayers {
+		root := layer.Path
+		for l := layer; l != nil && l.IncludedBy != ""; l = byPath[l.IncludedBy] {
+			root = l.IncludedBy
+		}
+		if root != layer.Path {
+			included[root] = append(included[root], layer)
+		}
+	}
+	mu.RUnlock()
+
+	var paths []ConfigPath
+	for _, scope := range Scopes {
+		var candidates []string
+		switch scope {
+		case ScopeSystem:
+			candidates = systemConfigFiles()
+		case ScopeProject:
+			_, candidates = findProjectFile()
+		default:
+			candidates = scopePaths(scope)
+		}
+		for _, path := range candidates {
+			if path == "" {
+				continue
+			}
+			paths = append(paths, ConfigPath{Path: path, Scope: scope, Loaded: byPath[path] != nil})
+			for _, layer := range included[path] {
+				paths = append(paths, ConfigPath{Path: layer.Path, Scope: scope, Loaded: true, IncludedBy: layer.IncludedBy})
+			}
+		}
+	}
+	return paths
+}
+
+// projectFileName returns the name of a project config file with the given extension
+func projectFileName(ext string) string {
+	return "." + consts.AppName + "." + ext
+}
+
+// findProjectFile looks for a project config file (.confapp.yaml, .confapp.json,
+// etc.) in the working directory and each of its parents, like git looks for
+// .git. Returns the first file found, or an empty path if there is none,
+// along with every path checked.
+func findProjectFile() (string, []string) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", nil
+	}
+
+	var candidates []string
+	for {
+		for _, ext := range validConfigFileExts {
+			path := filepath.Join(dir, projectFileName(ext))
+			candidates = append(candidates, path)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path, candidates
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", candidates
+		}
+		dir = parent
+	}
+}
+
+// configFile is a configuration file of a scope
+type configFile struct {
+	Path  string // Path of the file
+	Scope Scope  // Scope of the file
+}
+
+// systemConfigFiles returns the system config files, one in each system
+// config directory, by decreasing precedence.
+func systemConfigFiles() []string {
+	dirs := systemConfigDirs()
+	files := make([]string, len(dirs))
+	for i, dir := range dirs {
+		files[i] = filepath.Join(dir, consts.AppName, "config.yaml")
+	}
+	return files
+}
+
+// scopeFiles returns the configuration files of all scopes, in the order they
+// are merged.
+func scopeFiles() []configFile {
+	var files []configFile
+	for _, scope := range Scopes {
+		for _, path := range scopePaths(scope) {
+			files = append(files, configFile{Path: path, Scope: scope})
+		}
+	}
+	return files
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// SourceKind identifies the kind of source a configuration value came from
+type SourceKind string
+
+const (
+	SourceNone    SourceKind = "none"    // No value is set
+	SourceDefault SourceKind = "default" // Field.Default as declared in the source code
+	SourceLdflags SourceKind = "ldflags" // Field.Default overridden at build time with -ldflags
+	SourceFile    SourceKind = "file"    // A configuration file
+	SourceEnv     SourceKind = "env"     // An environment variable
+	SourceFlag    SourceKind = "flag"    // A command-line flag
+	SourceSet     SourceKind = "set"     // Set at runtime with WriteField
+)
+
+// Source describes where the resolved value of a field came from
+type Source struct {
+	Kind  SourceKind // Kind of source
+	Name  string     // File path, environment variable, flag or build-time variable name
+	Scope Scope      // Scope of a file source
+}
+
+// String returns a human-readable description of the source
+// (e.g. "env CONFAPP_LOG_LEVEL" or "user file /home/me/.config/confapp/config.yaml").
+func (s Source) String() string {
+	str := string(s.Kind)
+	if s.Scope != "" {
+		str = string(s.Scope) + " " + str
+	}
+	if s.Name != "" {
+		str += " " + s.Name
+	}
+	return str
+}
+
+// boundFlags holds the command-line flags bound to fields with BindFlag
+var boundFlags = make(map[string]*pflag.Flag)
+
+// BindFlag binds a command-line flag to a field, so that the flag value
+// takes precedence over the environment, config files and defaults when set.
+func BindFlag(f *Field, flag *pflag.Flag) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := viper.BindPFlag(f.Name, flag); err != nil {
+		return err
+	}
+	boundFlags[f.Name] = flag
+	return nil
+}
+
+// Layer is a source that may provide a value for a field
+type Layer struct {
+	Source Source // Where the value comes from
+	Value  any    // Value provided by the layer, if Set
+	Set    bool   // Whether the layer provides a value
+}
+
+// Explain returns every layer that can provide a value for a field, in order of
+// precedence (highest first). The first layer that is Set provides the resolved
+// value. Layers that could provide a value but do not (e.g. an unset environment
+// variable) are included with Set false.
+func Explain(f *Field) []Layer {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var layers []Layer
+
+	if _, ok := setKeys[f.Name]; ok {
+		layers = append(layers, Layer{Source: Source{Kind: SourceSet}, Value: viper.Get(f.Name), Set: true})
+	}
+
+	flag, hasFlag := boundFlags[f.Name]
+	if hasFlag {
+		layers = append(layers, Layer{
+			Source: Source{Kind: SourceFlag, Name: "--" + flag.Name},
+			Value:  flag.Value.String(),
+			Set:    flag.Changed,
+		})
+	}
+
+	// Empty environment variables are ignored by viper
+	env := EnvVarName(f)
+	envVal := os.Getenv(env)
+	layers = append(layers, Layer{Source: Source{Kind: SourceEnv, Name: env}, Value: envVal, Set: envVal != ""})
+
+	// Global flags are not read from config files
+	if !slices.Contains(flagFields, f) {
+		for i := len(fileLayers) - 1; i >= 0; i-- {
+			val, ok := lookupSetting(fileLayers[i].Settings, f.Name)
+			layers = append(layers, Layer{Source: fileLayers[i].source(), Value: val, Set: ok})
+		}
+	}
+
+	if f.DefaultVar != "" {
+		layers = append(layers, Layer{
+			Source: Source{Kind: SourceLdflags, Name: f.DefaultVar},
+			Value:  f.Default,
+			Set:    f.DefaultOverridden() && f.Default != nil,
+		})
+		// The declared default no longer applies if overridden, even with an empty value
+		layers = append(layers, Layer{
+			Source: Source{Kind: SourceDefault},
+			Value:  f.DeclaredDefault,
+			Set:    f.DeclaredDefault != nil && !f.DefaultOverridden(),
+		})
+	} else {
+		layers = append(layers, Layer{Source: Source{Kind: SourceDefault}, Value: f.Default, Set: f.Default != nil})
+	}
+
+	// Unchanged flags provide their default value
+	if hasFlag {
+		layers = append(layers, Layer{
+			Source: Source{Kind: SourceDefault, Name: "--" + flag.Name},
+			Value:  flag.DefValue,
+			Set:    !flag.Changed,
+		})
+	}
+
+	return layers
+}
+
+// SourceOf returns the source of the resolved value of a field.
+// Sources are checked in viper's order of precedence: values set at runtime,
+// flags, environment variables, config files and defaults.
+func SourceOf(f *Field) Source {
+	for _, layer := range Explain(f) {
+		if layer.Set {
+			return layer.Source
+		}
+	}
+	return Source{Kind: SourceNone}
+}
+
+// lookupSetting finds a dotted key in nested settings.
+// Keys are matched case-insensitively, like viper does.
+func lookupSetting(settings map[string]any, key string) (any, bool) {
+	parent, leaf, found := strings.Cut(strings.ToLower(key), ".")
+	if !found {
+		val, ok := settings[parent]
+		return val, ok
+	}
+
+	child, ok := settings[parent].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupSetting(child, leaf)
+}
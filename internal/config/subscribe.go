@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// subscriber receives the changes of the fields it selects
+type subscriber struct {
+	match  func(f *Field) bool // Selects the fields of interest
+	notify func([]Change)      // Receives the changes of the selected fields
+}
+
+var (
+	subsMu sync.Mutex
+	subs   []*subscriber
+)
+
+// Subscription is returned by OnChange and OnGroupChange to stop receiving changes
+type Subscription struct {
+	sub *subscriber
+}
+
+// Unsubscribe stops the delivery of changes to the subscriber.
+// Calling it more than once has no effect.
+func (s *Subscription) Unsubscribe() {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+
+	subs = slices.DeleteFunc(subs, func(sub *subscriber) bool { return sub == s.sub })
+}
+
+// OnChange calls fn with the previous and the new resolved value whenever the
+// value of a field changes, after a Reload or a WriteField call. Values hold
+// the Go type of the field (e.g. time.Duration for a duration field), whatever
+// their source.
+//
+// Subscribers are called synchronously in the order they subscribed, and may
+// read the configuration. A panic in a subscriber is recovered and reported to
+// the warning handler (see WithWarningHandler), without affecting the other
+// subscribers.
+func OnChange(f *Field, fn func(old, new any)) *Subscription {
+	return subscribe(&subscriber{
+		match: func(field *Field) bool { return field == f },
+		notify: func(changes []Change) {
+			for _, change := range changes {
+				fn(change.Old, change.New)
+			}
+		},
+	})
+}
+
+// OnGroupChange calls fn with the changes of the fields of a group (e.g.
+// GroupNetwork), in field registration order. Changes applied together, such
+// as those of a single Reload, are delivered in a single call.
+// Delivery follows the same rules as OnChange.
+func OnGroupChange(group string, fn func(changes []Change)) *Subscription {
+	return subscribe(&subscriber{
+		match:  func(field *Field) bool { return field.Group == group },
+		notify: fn,
+	})
+}
+
+// subscribe registers a subscriber
+func subscribe(sub *subscriber) *Subscription {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+
+	subs = append(subs, sub)
+	return &Subscription{sub: sub}
+}
+
+// notify delivers changes to the subscribers. Must be called without mu held,
+// so that subscribers can read the configuration.
+func notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	subsMu.Lock()
+	current := slices.Clone(subs)
+	subsMu.Unlock()
+
+	for _, sub := range current {
+		var selected []Change
+		for _, change := range changes {
+			if sub.match(change.Field) {
+				selected = append(selected, change)
+			}
+		}
+		if len(selected) > 0 {
+			deliver(sub, selected)
+		}
+	}
+}
+
+// deliver calls a subscriber, recovering from panics
+func deliver(sub *subscriber, changes []Change) {
+	defer func() {
+		if r := recover(); r != nil {
+			names := make([]string, len(changes))
+			for i, change := range changes {
+				names[i] = change.Field.Name
+			}
+			warn(fmt.Errorf("change subscriber for %v panicked: %v", names, r))
+		}
+	}()
+	sub.notify(changes)
+}
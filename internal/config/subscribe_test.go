@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// writeTestField sets a field with WriteField, and clears it when the test ends
+func writeTestField(t *testing.T, f *Field, value any) {
+	t.Helper()
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		viper.Set(f.Name, nil)
+		delete(setKeys, f.Name)
+	})
+	if err := WriteField(f, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOnChangeOrder(t *testing.T) {
+	var calls []string
+	subscriber := func(name string) func(old, new any) {
+		return func(old, new any) { calls = append(calls, name) }
+	}
+	a := OnChange(FieldAppLogLevel.Field, subscriber("a"))
+	defer a.Unsubscribe()
+	b := OnChange(FieldAppLogLevel.Field, subscriber("b"))
+	defer b.Unsubscribe()
+	c := OnChange(FieldAppLogLevel.Field, subscriber("c"))
+	defer c.Unsubscribe()
+	other := OnChange(FieldAppLogFormat.Field, subscriber("other"))
+	defer other.Unsubscribe()
+
+	writeTestField(t, FieldAppLogLevel.Field, "warn")
+	if want := []string{"a", "b", "c"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	// Unsubscribing twice has no effect
+	b.Unsubscribe()
+	b.Unsubscribe()
+	calls = nil
+	writeTestField(t, FieldAppLogLevel.Field, "error")
+	if want := []string{"a", "c"}; !slices.Equal(calls, want) {
+		t.Errorf("calls after unsubscribe = %v, want %v", calls, want)
+	}
+
+	// Unchanged values are not delivered
+	calls = nil
+	writeTestField(t, FieldAppLogLevel.Field, "error")
+	if len(calls) != 0 {
+		t.Errorf("calls for an unchanged value = %v, want none", calls)
+	}
+}
+
+func TestOnChangePanic(t *testing.T) {
+	var warnings []error
+	warn = func(err error) { warnings = append(warnings, err) }
+	defer func() { warn = defaultWarn }()
+
+	var calls []string
+	first := OnChange(FieldAppLogLevel.Field, func(old, new any) {
+		calls = append(calls, "first")
+		panic("boom")
+	})
+	defer first.Unsubscribe()
+	second := OnChange(FieldAppLogLevel.Field, func(old, new any) {
+		calls = append(calls, "second")
+	})
+	defer second.Unsubscribe()
+
+	writeTestField(t, FieldAppLogLevel.Field, "debug")
+	if want := []string{"first", "second"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "boom") {
+		t.Errorf("warnings = %v, want the panic of the first subscriber", warnings)
+	}
+}
+
+// TestOnChangeTypes checks that subscribers receive values of the field type,
+// whether they come from a file or from WriteField
+func TestOnChangeTypes(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfgFile, []byte("update:\n  period: 90\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	files := configFiles
+	configFiles = []configFile{{Path: cfgFile}}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		configFiles = files
+		mu.Unlock()
+		if _, err := applyLayers(nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var got [][2]any
+	sub := OnChange(FieldAppUpdatePeriod.Field, func(old, new any) {
+		got = append(got, [2]any{old, new})
+	})
+	defer sub.Unsubscribe()
+
+	if _, err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	writeTestField(t, FieldAppUpdatePeriod.Field, "1h")
+
+	want := [][2]any{
+		{time.Duration(0), 90 * time.Second},
+		{90 * time.Second, time.Hour},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// FieldValue is the set of Go types that can back a configuration field.
+// Each type corresponds to exactly one FieldType.
+type FieldValue interface {
+	string | bool | int | float64 | time.Duration
+}
+
+// TypedField binds a Field to the Go type of its values.
+// Reading through a TypedField never requires choosing the right accessor for
+// Field.Type: the type is fixed when the field is declared.
+type TypedField[T FieldValue] struct {
+	*Field
+}
+
+// NewTypedField wraps a field definition with its Go type.
+// If the field has no Type, it is derived from T. Panics if Type does not
+// correspond to T or if Default is not a T, so mismatches are caught when the
+// package is initialized rather than when the value is read.
+func NewTypedField[T FieldValue](f *Field) *TypedField[T] {
+	want := FieldTypeOf[T]()
+	if f.Type == "" {
+		f.Type = want
+	}
+	if f.Type != want {
+		log.Panicf("Field %s: type %s cannot be accessed as %s", f.Name, f.Type, want)
+	}
+	if err := f.checkDefault(); err != nil {
+		log.Panicf("Field %s: %v", f.Name, err)
+	}
+	return &TypedField[T]{Field: f}
+}
+
+// Get returns the resolved value of the field.
+// Returns an error if the value cannot be converted to T.
+func (f *TypedField[T]) Get() (T, error) {
+	return convertValue[T](getRaw(f.Name))
+}
+
+// Value returns the resolved value of the field.
+// Falls back to the default value (or the zero value of T, if there is no
+// default) when the resolved value cannot be converted to T.
+func (f *TypedField[T]) Value() T {
+	if v, err := f.Get(); err == nil {
+		return v
+	}
+	v, _ := f.Field.Default.(T)
+	return v
+}
+
+// Get reads the resolved value of a field as T.
+// Returns an error if the field type does not correspond to T or if the value
+// cannot be converted to T.
+func Get[T FieldValue](f *Field) (T, error) {
+	var zero T
+	if want := FieldTypeOf[T](); f.Type != want {
+		return zero, fmt.Errorf("%s: type %s cannot be read as %s", f.Name, f.Type, want)
+	}
+	return convertValue[T](getRaw(f.Name))
+}
+
+// FieldTypeOf returns the FieldType that corresponds to the Go type T.
+func FieldTypeOf[T FieldValue]() FieldType {
+	var zero T
+	return fieldTypeOfValue(zero)
+}
+
+// FormatValue formats a value of the field for display.
+// Durations are printed in their shortest form (e.g. "15m" rather than "15m0s"),
+// and a nil value is formatted as an empty string.
+func (f *Field) FormatValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	if f.Type == FieldTypeDuration {
+		if d, err := toDuration(v); err == nil {
+			return formatDuration(d)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// formatDuration formats a duration without trailing zero units
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// zeroValue returns the zero value of the Go type of a FieldType,
+// or nil for an unknown type.
+func zeroValue(t FieldType) any {
+	switch t {
+	case FieldTypeString:
+		return ""
+	case FieldTypeBool:
+		return false
+	case FieldTypeInt:
+		return 0
+	case FieldTypeFloat:
+		return 0.0
+	case FieldTypeDuration:
+		return time.Duration(0)
+	default:
+		return nil
+	}
+}
+
+// fieldTypeOfValue returns the FieldType for the dynamic type of v,
+// or an empty FieldType if v is not a FieldValue.
+func fieldTypeOfValue(v any) FieldType {
+	switch v.(type) {
+	case string:
+		return FieldTypeString
+	case bool:
+		return FieldTypeBool
+	case int:
+		return FieldTypeInt
+	case float64:
+		return FieldTypeFloat
+	case time.Duration:
+		return FieldTypeDuration
+	default:
+		return ""
+	}
+}
+
+// convertValue converts a raw configuration value to T.
+// A nil value converts to the zero value of T.
+func convertValue[T FieldValue](v any) (T, error) {
+	var zero T
+	if v == nil {
+		return zero, nil
+	}
+
+	var (
+		out any
+		err error
+	)
+	switch any(zero).(type) {
+	case string:
+		out, err = cast.ToStringE(v)
+	case bool:
+		out, err = cast.ToBoolE(v)
+	case int:
+		out, err = cast.ToIntE(v)
+	case float64:
+		out, err = cast.ToFloat64E(v)
+	case time.Duration:
+		out, err = toDuration(v)
+	}
+	if err != nil {
+		return zero, fmt.Errorf("cannot convert %v (%T) to %s: %w", v, v, FieldTypeOf[T](), err)
+	}
+	return out.(T), nil
+}
+
+// toDuration converts a value to a duration.
+// Numeric values, and strings holding only a number, are interpreted as seconds.
+func toDuration(v any) (time.Duration, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(secs * float64(time.Second)), nil
+		}
+		return time.ParseDuration(v)
+	default:
+		secs, err := cast.ToFloat64E(v)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+)
+
+// UnknownKeyError reports a key, set in a config file or environment variable,
+// that does not match any configuration field
+type UnknownKeyError struct {
+	Key        string // Unknown key (or environment variable name)
+	Source     Source // Where the key was found
+	Line       int    // Line of the key in the source file, if known
+	Column     int    // Column of the key in the source file, if known
+	Suggestion string // Closest known key, if any is close enough
+}
+
+func (e *UnknownKeyError) Error() string {
+	var msg string
+	switch {
+	case e.Source.Kind == SourceEnv:
+		msg = fmt.Sprintf("unknown environment variable %s", e.Key)
+	case e.Line > 0:
+		msg = fmt.Sprintf("%s:%d:%d: unknown key %q", e.Source.Name, e.Line, e.Column, e.Key)
+	default:
+		msg = fmt.Sprintf("unknown key %q in %s", e.Key, e.Source)
+	}
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+// KnownFields returns all fields that may be set in the environment: the
+// registered Fields and the global flag fields (e.g. CONFAPP_CONFIG).
+// Config files may only hold the registered Fields, see fieldNames.
+func KnownFields() FieldCollection {
+	return slices.Concat(Fields, flagFields)
+}
+
+// fieldNames returns the names of the registered fields, which are the keys
+// config files may hold. Global flag fields are not settings: a config file
+// setting the config file would redirect later saves to another file.
+func fieldNames() []string {
+	names := make([]string, 0, len(Fields))
+	for _, field := range Fields {
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+// UnknownKeys compares the keys of the loaded config files against the
+// registered fields, and the environment variables with the configuration
+// prefix against the known fields, and returns an error for each key that does
+// not match, with a suggestion for typos.
+func UnknownKeys() []*UnknownKeyError {
+	fields := KnownFields()
+	names := fieldNames()
+	envNames := make([]string, 0, len(fields))
+	for _, field := range fields {
+		envNames = append(envNames, EnvVarName(field))
+	}
+
+	var unknown []*UnknownKeyError
+	mu.RLock()
+	for _, layer := range fileLayers {
+		unknown = append(unknown, unknownSettings(layer.source(), layer.Settings, names)...)
+	}
+	mu.RUnlock()
+
+	var envKeys []string
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, consts.ConfigEnvPrefix+"_") && !slices.Contains(envNames, name) {
+			envKeys = append(envKeys, name)
+		}
+	}
+	slices.Sort(envKeys)
+	for _, name := range envKeys {
+		unknown = append(unknown, &UnknownKeyError{
+			Key:        name,
+			Source:     Source{Kind: SourceEnv, Name: name},
+			Suggestion: closestMatch(name, envNames),
+		})
+	}
+
+	return unknown
+}
+
+// unknownSettings returns an error for each key of the settings read from a
+// config file that is not one of the known names
+func unknownSettings(source Source, settings map[string]any, names []string) []*UnknownKeyError {
+	var unknown []*UnknownKeyError
+	for _, key := range settingKeys(settings, "") {
+		if !slices.Contains(names, key) {
+			unknown = append(unknown, &UnknownKeyError{
+				Key:        key,
+				Source:     source,
+				Suggestion: closestMatch(key, names),
+			})
+		}
+	}
+	return unknown
+}
+
+// settingKeys returns the dotted keys of the leaf values in nested settings, sorted
+func settingKeys(settings map[string]any, prefix string) []string {
+	var keys []string
+	for key, val := range settings {
+		if child, ok := val.(map[string]any); ok && len(child) > 0 {
+			keys = append(keys, settingKeys(child, prefix+key+".")...)
+			continue
+		}
+		keys = append(keys, prefix+key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// closestMatch returns the candidate closest to key by edit distance,
+// or an empty string if none is close enough to be a likely typo.
+func closestMatch(key string, candidates []string) string {
+	best, bestDist := "", max(2, len(key)/3)+1
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
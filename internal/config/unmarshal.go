@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// structTag is the struct tag that binds a struct field to a configuration field
+const structTag = "config"
+
+// BindingError reports the differences between a struct and the Fields registry.
+type BindingError struct {
+	Unbound    []string // Struct fields with no matching Field
+	Unmapped   []string // Fields with no matching struct field
+	Mismatched []string // Struct fields whose Go type does not match the Field type
+}
+
+func (e *BindingError) Error() string {
+	var parts []string
+	if len(e.Unbound) > 0 {
+		parts = append(parts, fmt.Sprintf("struct fields without a config field: %s", strings.Join(e.Unbound, ", ")))
+	}
+	if len(e.Unmapped) > 0 {
+		parts = append(parts, fmt.Sprintf("config fields without a struct field: %s", strings.Join(e.Unmapped, ", ")))
+	}
+	if len(e.Mismatched) > 0 {
+		parts = append(parts, fmt.Sprintf("struct fields with mismatched types: %s", strings.Join(e.Mismatched, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// boundField is a struct field bound to a configuration field
+type boundField struct {
+	field *Field
+	value reflect.Value
+}
+
+// Unmarshal decodes the resolved configuration into out, which must be a
+// pointer to a struct tagged as described in AppConfig.
+// Every value is validated against its Field, and failures are reported as
+// ValidationErrors. Returns a *BindingError (joined with any validation errors)
+// if the struct and Fields have drifted apart.
+func Unmarshal(out any) error {
+	var errs []error
+	bound, err := bindStruct(out)
+	if err != nil {
+		if bindErr := (*BindingError)(nil); !errors.As(err, &bindErr) {
+			return err
+		}
+		errs = append(errs, err)
+	}
+
+	var verrs ValidationErrors
+	for _, b := range bound {
+		raw := getRaw(b.field.Name)
+		if verr := b.field.check(raw); verr != nil {
+			verr.Source = SourceOf(b.field)
+			verrs = append(verrs, verr)
+			continue
+		}
+
+		// The value was checked, so the conversion succeeds
+		if val, _ := readTyped(b.field, raw); val != nil {
+			b.value.Set(reflect.ValueOf(val))
+		}
+	}
+
+	return errors.Join(append(errs, verrs.OrNil())...)
+}
+
+// CheckBinding verifies that every struct field of out (a pointer to a tagged
+// struct) is bound to a Field of the same type, and every Field is bound to a
+// struct field. Returns a *BindingError describing any differences.
+func CheckBinding(out any) error {
+	_, err := bindStruct(out)
+	return err
+}
+
+// bindStruct matches the tagged fields of out against Fields.
+func bindStruct(out any) ([]boundField, error) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unmarshal target must be a non-nil pointer to a struct, got %T", out)
+	}
+
+	fields := Fields.Map()
+	seen := make(map[string]bool)
+	bindErr := &BindingError{}
+	var bound []boundField
+
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name := sf.Tag.Get(structTag)
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(sf.Name)
+			}
+			key := prefix + name
+
+			if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+				walk(v.Field(i), key+".")
+				continue
+			}
+
+			field, ok := fields[key]
+			if !ok {
+				bindErr.Unbound = append(bindErr.Unbound, fmt.Sprintf("%s (%s)", sf.Name, key))
+				continue
+			}
+			seen[key] = true
+			if goTypeOf(field.Type) != sf.Type {
+				bindErr.Mismatched = append(bindErr.Mismatched,
+					fmt.Sprintf("%s (%s is %s, want %s)", sf.Name, key, sf.Type, field.Type))
+				continue
+			}
+			bound = append(bound, boundField{field: field, value: v.Field(i)})
+		}
+	}
+	walk(rv.Elem(), "")
+
+	for name := range fields {
+		if !seen[name] {
+			bindErr.Unmapped = append(bindErr.Unmapped, name)
+		}
+	}
+	slices.Sort(bindErr.Unmapped)
+
+	if len(bindErr.Unbound) > 0 || len(bindErr.Unmapped) > 0 || len(bindErr.Mismatched) > 0 {
+		return bound, bindErr
+	}
+	return bound, nil
+}
+
+// durationType is the reflect type of time.Duration
+var durationType = reflect.TypeFor[time.Duration]()
+
+// goTypeOf returns the Go type that backs values of the given FieldType
+func goTypeOf(t FieldType) reflect.Type {
+	switch t {
+	case FieldTypeString:
+		return reflect.TypeFor[string]()
+	case FieldTypeBool:
+		return reflect.TypeFor[bool]()
+	case FieldTypeInt:
+		return reflect.TypeFor[int]()
+	case FieldTypeFloat:
+		return reflect.TypeFor[float64]()
+	case FieldTypeDuration:
+		return durationType
+	default:
+		return nil
+	}
+}
+
+// readTyped converts a raw value to the Go type of the field.
+// A nil value returns nil.
+func readTyped(f *Field, raw any) (any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	switch f.Type {
+	case FieldTypeString:
+		return convertValue[string](raw)
+	case FieldTypeBool:
+		return convertValue[bool](raw)
+	case FieldTypeInt:
+		return convertValue[int](raw)
+	case FieldTypeFloat:
+		return convertValue[float64](raw)
+	case FieldTypeDuration:
+		return convertValue[time.Duration](raw)
+	default:
+		return nil, fmt.Errorf("unsupported field type: %s", f.Type)
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// FileError is an error reading or parsing a configuration file
+type FileError struct {
+	Path   string // Path of the file
+	Line   int    // Line number, starting at 1 (0 if unknown)
+	Column int    // Column number, starting at 1 (0 if unknown)
+	Err    error  // Underlying error
+}
+
+func (e *FileError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Path)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// position is a line and column in a file
+type position struct {
+	Line, Column int
+}
+
+// ValidateFile reads a configuration file and validates the value of every
+// field it sets, then validates the files it includes and the fragments of its
+// drop-in directory in the same way, in merge order. Returns a *FileError if
+// the file cannot be parsed; otherwise all failures are reported at once,
+// joined in file order: a *ValidationError for each invalid value and an
+// *UnknownKeyError for each key that does not match a field. Failures of the
+// other files follow, each being reported with its own file, or the error
+// resolving the includes.
+func ValidateFile(cfgFile string) error {
+	if err := FieldFlagConfig.Validate(cfgFile); err != nil {
+		return &FileError{Path: cfgFile, Err: err}
+	}
+
+	err := validateFile(cfgFile)
+	var ferr *FileError
+	if errors.As(err, &ferr) {
+		return err
+	}
+
+	layers, lerr := readConfigLayers(configFile{Path: cfgFile})
+	if lerr != nil {
+		// The error names the file whose includes cannot be resolved
+		return errors.Join(err, lerr)
+	}
+	errs := []error{err}
+	for _, layer := range layers {
+		if layer.Path != cfgFile {
+			errs = append(errs, validateFile(layer.Path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateFile validates the settings of a single configuration file,
+// see ValidateFile
+func validateFile(cfgFile string) error {
+
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetConfigType(configFileType(cfgFile))
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		line, col := parseErrorPosition(err)
+		return &FileError{Path: cfgFile, Line: line, Column: col, Err: err}
+	}
+
+	positions := keyPositions(cfgFile, data)
+	settings := v.AllSettings()
+	if _, err := includePatterns(settings[IncludeKey]); err != nil {
+		pos := positions[IncludeKey]
+		return &FileError{Path: cfgFile, Line: pos.Line, Column: pos.Column, Err: err}
+	}
+	delete(settings, IncludeKey)
+
+	var errs []error
+	for _, field := range Fields {
+		val, ok := lookupSetting(settings, field.Name)
+		if !ok {
+			continue
+		}
+
+		if verr := field.check(val); verr != nil {
+			pos := positions[field.Name]
+			verr.Source = Source{Kind: SourceFile, Name: cfgFile}
+			verr.Line, verr.Column = pos.Line, pos.Column
+			errs = append(errs, verr)
+		}
+	}
+
+	for _, uerr := range unknownSettings(Source{Kind: SourceFile, Name: cfgFile}, settings, fieldNames()) {
+		pos := positions[uerr.Key]
+		uerr.Line, uerr.Column = pos.Line, pos.Column
+		errs = append(errs, uerr)
+	}
+
+	// Report errors in the order they appear in the file
+	slices.SortStableFunc(errs, func(a, b error) int {
+		pa, pb := errorPosition(a), errorPosition(b)
+		return cmp.Or(cmp.Compare(pa.Line, pb.Line), cmp.Compare(pa.Column, pb.Column))
+	})
+
+	return errors.Join(errs...)
+}
+
+// errorPosition returns the position in its file of a validation or unknown
+// key error
+func errorPosition(err error) position {
+	switch err := err.(type) {
+	case *ValidationError:
+		return position{Line: err.Line, Column: err.Column}
+	case *UnknownKeyError:
+		return position{Line: err.Line, Column: err.Column}
+	default:
+		return position{}
+	}
+}
+
+// configFileType returns the format of a config file, determined by its extension
+func configFileType(cfgFile string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(cfgFile), "."))
+}
+
+// parseErrorLine matches the line number in yaml and toml parse errors
+var parseErrorLine = regexp.MustCompile(`line (\d+)(?:, column (\d+)|:(\d+))?`)
+
+// parseErrorPosition extracts the position from a parse error message, if any
+func parseErrorPosition(err error) (line, col int) {
+	m := parseErrorLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, 0
+	}
+	line, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		col, _ = strconv.Atoi(m[2])
+	} else if m[3] != "" {
+		col, _ = strconv.Atoi(m[3])
+	}
+	return line, col
+}
+
+// keyPositions maps the dotted keys of a config file to the position of their values.
+// Positions are available for yaml, json and toml files.
+func keyPositions(cfgFile string, data []byte) map[string]position {
+	positions := make(map[string]position)
+
+	switch configFileType(cfgFile) {
+	case "yaml", "yml", "json":
+		// JSON is a subset of YAML, so both are parsed as YAML
+		var root yaml.Node
+		if yaml.Unmarshal(data, &root) == nil && len(root.Content) > 0 {
+			yamlKeyPositions(root.Content[0], "", positions)
+		}
+	case "toml":
+		tomlKeyPositions(data, positions)
+	}
+
+	return positions
+}
+
+// yamlKeyPositions records the positions of the values under a YAML mapping node
+func yamlKeyPositions(node *yaml.Node, prefix string, positions map[string]position) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		name := prefix + strings.ToLower(key.Value)
+		positions[name] = position{Line: val.Line, Column: val.Column}
+		yamlKeyPositions(val, name+".", positions)
+	}
+}
+
+// tomlTable matches a TOML table header like [log] or [proxy]
+var tomlTable = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9_.\-]+)\s*\]`)
+
+// tomlKey matches a TOML key/value line like level = "debug"
+var tomlKey = regexp.MustCompile(`^(\s*)([A-Za-z0-9_.\-]+)\s*=\s*`)
+
+// tomlKeyPositions records the positions of the values in a TOML document.
+// It only understands tables and (dotted) bare keys, which covers config files.
+func tomlKeyPositions(data []byte, positions map[string]position) {
+	prefix := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if m := tomlTable.FindStringSubmatch(text); m != nil {
+			prefix = strings.ToLower(m[1]) + "."
+			continue
+		}
+		if m := tomlKey.FindStringSubmatch(text); m != nil {
+			positions[prefix+strings.ToLower(m[2])] = position{Line: line, Column: len(m[0]) + 1}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -11,29 +12,32 @@ import (
 // validConfigFileExts defines the supported configuration file extensions
 var validConfigFileExts = []string{"yaml", "yml", "json", "toml", "hcl", "env"}
 
-// validateConfigFile validates that a config file path has a supported extension.
-// Returns an error if the file extension is not supported, or nil if valid.
+// validateConfigFile validates that config file paths have a supported extension.
+// The value is a path list, with paths separated by os.PathListSeparator.
+// Returns an error if a file extension is not supported, or nil if valid.
 func validateConfigFile(v any) error {
 	val, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("config file path must be a string")
 	}
 
-	if val == "" {
-		return nil // empty value is allowed
-	}
+	for _, cfgFile := range filepath.SplitList(val) {
+		if cfgFile == "" {
+			continue // empty value is allowed
+		}
 
-	// Extract file extension and validate
-	cfgFileExt := strings.ToLower(path.Ext(val))
-	if cfgFileExt == "" {
-		return fmt.Errorf("config file must have an extension")
-	}
+		// Extract file extension and validate
+		cfgFileExt := strings.ToLower(path.Ext(cfgFile))
+		if cfgFileExt == "" {
+			return fmt.Errorf("config file must have an extension: %s", cfgFile)
+		}
 
-	// Remove the leading dot from extension
-	cfgFileExt = cfgFileExt[1:]
+		// Remove the leading dot from extension
+		cfgFileExt = cfgFileExt[1:]
 
-	if !slices.Contains(validConfigFileExts, cfgFileExt) {
-		return fmt.Errorf("unsupported file extension: %s (supported: %v)", cfgFileExt, validConfigFileExts)
+		if !slices.Contains(validConfigFileExts, cfgFileExt) {
+			return fmt.Errorf("unsupported file extension: %s (supported: %v)", cfgFileExt, validConfigFileExts)
+		}
 	}
 
 	return nil
@@ -44,6 +48,8 @@ func validateConfigFile(v any) error {
 // Returns an error if the value cannot be parsed as a duration.
 func validateDuration(v any) error {
 	switch v := v.(type) {
+	case time.Duration:
+		return nil
 	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
 		// Numeric values are valid (interpreted as seconds)
 		return nil
@@ -54,8 +60,8 @@ func validateDuration(v any) error {
 		if v == "" {
 			return nil // empty value is allowed
 		}
-		_, err := time.ParseDuration(v)
-		if err != nil {
+		// Strings holding only a number are interpreted as seconds
+		if _, err := toDuration(v); err != nil {
 			return fmt.Errorf("invalid duration format: %s (examples: 1h30m, 15m, 10s)", v)
 		}
 		return nil
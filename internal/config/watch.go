@@ -0,0 +1,281 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+	"github.com/spf13/viper"
+)
+
+// watchDebounce is how long Watch waits for a burst of file events to settle
+// before reloading. Editors often write a file in several steps.
+const watchDebounce = 100 * time.Millisecond
+
+// Change describes a configuration field whose resolved value changed.
+// Old and New hold the Go type of the field (see FieldValue) whatever the
+// source of the values, an unset value being the zero value of the type.
+type Change struct {
+	Field *Field // Field that changed
+	Old   any    // Previous resolved value
+	New   any    // New resolved value
+}
+
+// newChange returns the change of a field from its previous to its new
+// resolved value, converted to the Go type of the field. A value that cannot
+// be converted, which fails validation, is kept as it is.
+func newChange(f *Field, old, new any) Change {
+	return Change{Field: f, Old: typedValue(f, old), New: typedValue(f, new)}
+}
+
+// typedValue converts a resolved value to the Go type of a field
+func typedValue(f *Field, value any) any {
+	typed, err := readTyped(f, value)
+	if err != nil {
+		return value
+	}
+	if typed == nil {
+		return zeroValue(f.Type)
+	}
+	return typed
+}
+
+// String formats the change for logging, e.g. "log.level: info -> debug".
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field.Name, c.Field.FormatValue(c.Old), c.Field.FormatValue(c.New))
+}
+
+// Reload reads the configuration files again and applies them.
+// The resulting configuration is validated as a whole before it is applied:
+// if any field is invalid, the previous configuration is kept and the
+// validation errors are returned. Returns the fields whose resolved value
+// changed, in registration order, after notifying their subscribers.
+func Reload() ([]Change, error) {
+	mu.RLock()
+	files := slices.Clone(configFiles)
+	mu.RUnlock()
+
+	var layers []*fileLayer
+	for _, file := range files {
+		read, err := readConfigLayers(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Path, err)
+		}
+		layers = append(layers, read...)
+	}
+
+	changes, err := applyLayers(layers)
+	if err != nil {
+		return nil, err
+	}
+	notify(changes)
+	return changes, nil
+}
+
+// applyLayers validates the configuration resulting from the file layers and
+// replaces the current file layers with them.
+func applyLayers(layers []*fileLayer) ([]Change, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := validateLayers(layers); err != nil {
+		return nil, fmt.Errorf("invalid configuration, keeping the previous one:\n%w", err)
+	}
+
+	old := make([]any, len(Fields))
+	for i, field := range Fields {
+		old[i] = viper.Get(field.Name)
+	}
+
+	// Reading an empty document discards the previously merged files
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader("")); err != nil {
+		return nil, fmt.Errorf("failed to reset configuration: %w", err)
+	}
+	for _, layer := range layers {
+		if err := viper.MergeConfigMap(layer.mergedSettings()); err != nil {
+			return nil, fmt.Errorf("failed to merge config file %s: %w", layer.Path, err)
+		}
+	}
+	fileLayers = layers
+
+	var changes []Change
+	for i, field := range Fields {
+		if val := viper.Get(field.Name); !sameValue(field, old[i], val) {
+			changes = append(changes, newChange(field, old[i], val))
+		}
+	}
+	return changes, nil
+}
+
+// validateLayers validates every field as it would resolve with the given
+// file layers in place of the current ones. Values set at runtime, flags and
+// environment variables keep their precedence over the files.
+// Must be called with mu held.
+func validateLayers(layers []*fileLayer) error {
+	v := viper.New()
+	v.AutomaticEnv()
+	v.SetEnvPrefix(consts.ConfigEnvPrefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	for _, field := range Fields {
+		if field.Default != nil {
+			v.SetDefault(field.Name, field.Default)
+		}
+	}
+	for _, layer := range layers {
+		if err := v.MergeConfigMap(layer.mergedSettings()); err != nil {
+			return err
+		}
+	}
+	for name, flag := range boundFlags {
+		if err := v.BindPFlag(name, flag); err != nil {
+			return err
+		}
+	}
+	for name := range setKeys {
+		v.Set(name, viper.Get(name))
+	}
+
+	var errs ValidationErrors
+	for _, field := range Fields {
+		verr := field.check(v.Get(field.Name))
+		if verr == nil {
+			continue
+		}
+		for i := len(layers) - 1; i >= 0; i-- {
+			if _, ok := lookupSetting(layers[i].Settings, field.Name); ok {
+				verr.Source = layers[i].source()
+				break
+			}
+		}
+		errs = append(errs, verr)
+	}
+	return errs.OrNil()
+}
+
+// sameValue reports whether two raw values of a field resolve to the same
+// typed value, so that e.g. "15m" and 900 are not reported as a change.
+func sameValue(f *Field, a, b any) bool {
+	ta, errA := readTyped(f, a)
+	tb, errB := readTyped(f, b)
+	if errA == nil && errB == nil {
+		return reflect.DeepEqual(ta, tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Watch watches the configuration files and reloads them when they change,
+// until ctx is canceled. Changed values, and reloads rejected because the
+// configuration is invalid, are logged with logf (log.Printf if nil).
+// Included files and drop-in fragments are watched too. Files that do not
+// exist yet are picked up once they are created.
+func Watch(ctx context.Context, logf func(format string, args ...any)) error {
+	if logf == nil {
+		logf = log.Printf
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	watched := make(map[string]struct{})
+	if err := addWatches(watcher, watched); err != nil {
+		return err
+	}
+	if len(watched) == 0 {
+		return errors.New("no config file to watch")
+	}
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(event.Name)
+			_, isWatched := watched[name]
+			_, inDropIn := watched[filepath.Dir(name)]
+			if !isWatched && !inDropIn {
+				continue
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logf("config watch error: %v", err)
+		case <-timer.C:
+			changes, err := Reload()
+			// Follow the files included since the last reload
+			if err := addWatches(watcher, watched); err != nil {
+				logf("config watch error: %v", err)
+			}
+			if err != nil {
+				logf("config reload failed: %v", err)
+				continue
+			}
+			for _, change := range changes {
+				logf("config changed: %s", change)
+			}
+		}
+	}
+}
+
+// addWatches watches the config files, the files they include and their
+// drop-in directories, and records their absolute paths in watched.
+// Directories are watched rather than the files themselves, so that files
+// replaced by a rename (as many editors do) are still followed. Files in
+// directories that do not exist are skipped.
+func addWatches(watcher *fsnotify.Watcher, watched map[string]struct{}) error {
+	mu.RLock()
+	var paths []string
+	for _, file := range configFiles {
+		paths = append(paths, file.Path, DropInDir(file.Path))
+	}
+	for _, layer := range fileLayers {
+		paths = append(paths, layer.Path)
+	}
+	mu.RUnlock()
+
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		dirs := []string{filepath.Dir(abs)}
+		if info, err := os.Stat(abs); err == nil && info.IsDir() {
+			dirs = append(dirs, abs)
+		}
+		for _, dir := range dirs {
+			if err := watcher.Add(dir); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return fmt.Errorf("failed to watch %s: %w", dir, err)
+			}
+			watched[abs] = struct{}{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/lucasdecamargo/go-appconfig-example/internal/consts"
+)
+
+// Directories follow the XDG Base Directory Specification on Unix systems.
+// Relative paths in the XDG variables are invalid and ignored, as the
+// specification requires.
+
+// DefaultUserConfigFile returns the default path of the user config file:
+// config.yaml in the application directory of $XDG_CONFIG_HOME, or of
+// os.UserConfigDir if it is not set (e.g. ~/.config/confapp/config.yaml).
+func DefaultUserConfigFile() string {
+	dir := xdgDir("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return ""
+		}
+	}
+	return filepath.Join(dir, consts.AppName, "config.yaml")
+}
+
+// StateDir returns the directory holding data derived from the configuration
+// that should persist between runs, such as its history:
+// the application directory of $XDG_STATE_HOME (by default ~/.local/state).
+func StateDir() string {
+	if dir := xdgDir("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, consts.AppName)
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		// No state directory convention, keep state next to the configuration
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(dir, consts.AppName, "state")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", consts.AppName)
+}
+
+// systemConfigDirs returns the system config directories by decreasing
+// precedence: the entries of $XDG_CONFIG_DIRS, or /etc if it is not set
+// (%ProgramData% on Windows). Never empty.
+func systemConfigDirs() []string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return []string{dir}
+		}
+		return []string{`C:\ProgramData`}
+	}
+
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"/etc"}
+	}
+	return dirs
+}
+
+// xdgDir returns the directory set in an XDG environment variable,
+// or an empty string if it is unset or not an absolute path.
+func xdgDir(env string) string {
+	dir := strings.TrimSpace(os.Getenv(env))
+	if !filepath.IsAbs(dir) {
+		return ""
+	}
+	return dir
+}